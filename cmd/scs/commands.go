@@ -0,0 +1,465 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Arvintian/scs-go-sdk/scs"
+)
+
+func cmdLs(c *cli, args []string) error {
+	flags := flag.NewFlagSet("ls", flag.ExitOnError)
+	recursive := flags.Bool("r", false, "list recursively")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		buckets, err := c.s.ListBuckets()
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(buckets))
+		for _, b := range buckets {
+			rows = append(rows, []string{b.Name, b.CreationDate, fmt.Sprint(b.ConsumedBytes)})
+		}
+		return c.print(buckets, []string{"NAME", "CREATED", "BYTES"}, rows)
+	}
+	if err := needArgs(flags, 1); err != nil {
+		return err
+	}
+	b, prefix, err := c.bucket(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	delimiter := "/"
+	if *recursive {
+		delimiter = ""
+	}
+	var result struct {
+		CommonPrefixes []scs.CommonPrefix
+		Contents       []scs.Object
+	}
+	err = b.ListPages(context.Background(), delimiter, prefix, func(lo scs.ListObject) error {
+		result.CommonPrefixes = append(result.CommonPrefixes, lo.CommonPrefixes...)
+		result.Contents = append(result.Contents, lo.Contents...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0)
+	for _, cp := range result.CommonPrefixes {
+		rows = append(rows, []string{"DIR", "", "", cp.Prefix})
+	}
+	for _, o := range result.Contents {
+		rows = append(rows, []string{o.LastModified, fmt.Sprint(o.Size), o.MD5, o.Name})
+	}
+	return c.print(result, []string{"LAST-MODIFIED", "SIZE", "MD5", "KEY"}, rows)
+}
+
+func cmdStat(c *cli, args []string) error {
+	flags := flag.NewFlagSet("stat", flag.ExitOnError)
+	flags.Parse(args)
+	if err := needArgs(flags, 1); err != nil {
+		return err
+	}
+	b, key, err := c.object(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	meta, err := b.Head(key)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{
+		{"Key", key},
+		{"Content-Type", meta.ContentType},
+		{"Content-Length", fmt.Sprint(meta.ContentLength)},
+		{"ETag", meta.ETag},
+		{"Last-Modified", meta.LastModified},
+	}
+	for _, h := range [][]string{
+		{"Cache-Control", meta.CacheControl},
+		{"Content-Encoding", meta.ContentEncoding},
+		{"Content-Disposition", meta.ContentDisposition},
+	} {
+		if h[1] != "" {
+			rows = append(rows, h)
+		}
+	}
+	for k, v := range meta.XAmzMeta {
+		rows = append(rows, []string{k, v})
+	}
+	return c.print(meta, nil, rows)
+}
+
+func cmdCat(c *cli, args []string) error {
+	flags := flag.NewFlagSet("cat", flag.ExitOnError)
+	flags.Parse(args)
+	if err := needArgs(flags, 1); err != nil {
+		return err
+	}
+	b, key, err := c.object(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	rc, err := b.Get(key, "")
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(os.Stdout, rc)
+	return err
+}
+
+func cmdCp(c *cli, args []string) error {
+	flags := flag.NewFlagSet("cp", flag.ExitOnError)
+	flags.Parse(args)
+	if err := needArgs(flags, 2); err != nil {
+		return err
+	}
+	return c.copy(flags.Arg(0), flags.Arg(1))
+}
+
+func cmdMv(c *cli, args []string) error {
+	flags := flag.NewFlagSet("mv", flag.ExitOnError)
+	flags.Parse(args)
+	if err := needArgs(flags, 2); err != nil {
+		return err
+	}
+	src, dst := flags.Arg(0), flags.Arg(1)
+	if isRemote(src) && isRemote(dst) {
+		srcBucket, srcKey, err := splitRemote(src)
+		if err != nil {
+			return err
+		}
+		if srcKey == "" {
+			return fmt.Errorf("missing key in %s", src)
+		}
+		b, key, err := c.bucket(dst)
+		if err != nil {
+			return err
+		}
+		if b.Name == srcBucket {
+			if key == "" || strings.HasSuffix(key, "/") {
+				key += path.Base(srcKey)
+			}
+			return b.Move(srcKey, key)
+		}
+	}
+	if err := c.copy(src, dst); err != nil {
+		return err
+	}
+	if !isRemote(src) {
+		return os.Remove(src)
+	}
+	b, key, err := c.object(src)
+	if err != nil {
+		return err
+	}
+	return b.Delete(key)
+}
+
+// copy 复制单个文件或object
+func (c *cli) copy(src, dst string) error {
+	switch {
+	case !isRemote(src) && isRemote(dst):
+		b, key, err := c.bucket(dst)
+		if err != nil {
+			return err
+		}
+		if key == "" || strings.HasSuffix(key, "/") {
+			key += filepath.Base(src)
+		}
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		meta := make(map[string]string)
+		if ctype := mime.TypeByExtension(path.Ext(key)); ctype != "" {
+			meta["Content-Type"] = ctype
+		}
+		return b.Put(key, meta, f)
+	case isRemote(src) && !isRemote(dst):
+		b, key, err := c.object(src)
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(dst); err == nil && info.IsDir() {
+			dst = filepath.Join(dst, path.Base(key))
+		}
+		rc, err := b.Get(key, "")
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		f, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, rc); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case isRemote(src) && isRemote(dst):
+		srcBucket, srcKey, err := splitRemote(src)
+		if err != nil {
+			return err
+		}
+		if srcKey == "" {
+			return fmt.Errorf("missing key in %s", src)
+		}
+		b, key, err := c.bucket(dst)
+		if err != nil {
+			return err
+		}
+		if key == "" || strings.HasSuffix(key, "/") {
+			key += path.Base(srcKey)
+		}
+		return b.Copy(key, srcBucket, srcKey)
+	default:
+		return errors.New("at least one of src and dst must be a scs:// path")
+	}
+}
+
+func cmdRm(c *cli, args []string) error {
+	flags := flag.NewFlagSet("rm", flag.ExitOnError)
+	recursive := flags.Bool("r", false, "remove all objects under the prefix")
+	force := flags.String("force", "", "required by -r on the bucket root, the value must match the bucket name (path.Match pattern)")
+	flags.Parse(args)
+	if err := needArgs(flags, 1); err != nil {
+		return err
+	}
+	if !*recursive {
+		b, key, err := c.object(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		return b.Delete(key)
+	}
+	b, key, err := c.bucket(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var n int
+	if key == "" {
+		n, err = b.DeleteAll(*force)
+	} else {
+		n, err = b.DeletePrefix(key)
+	}
+	fmt.Println("deleted", n, "objects")
+	return err
+}
+
+func cmdMb(c *cli, args []string) error {
+	flags := flag.NewFlagSet("mb", flag.ExitOnError)
+	acl := flags.String("acl", scs.ACLPrivate, "bucket acl")
+	flags.Parse(args)
+	if err := needArgs(flags, 1); err != nil {
+		return err
+	}
+	name, _, err := splitRemote(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.s.PutBucket(name, *acl)
+}
+
+func cmdRb(c *cli, args []string) error {
+	flags := flag.NewFlagSet("rb", flag.ExitOnError)
+	force := flags.String("force", "", "delete all objects first, the value must match the bucket name (path.Match pattern)")
+	flags.Parse(args)
+	if err := needArgs(flags, 1); err != nil {
+		return err
+	}
+	name, _, err := splitRemote(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *force == "" {
+		return c.s.DeleteBucket(name)
+	}
+	return c.s.DeleteBucketForce(context.Background(), name, &scs.ForceDeleteOptions{
+		Confirm: *force,
+		Progress: func(p scs.ForceDeleteProgress) {
+			fmt.Fprintf(os.Stderr, "deleted %d, failed %d, aborted uploads %d\n", p.Deleted, p.Failed, p.Aborted)
+		},
+	})
+}
+
+func cmdSync(c *cli, args []string) error {
+	var include, exclude stringsFlag
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	del := flags.Bool("delete", false, "delete extraneous files from dst")
+	dryRun := flags.Bool("dryrun", false, "only show what would be done")
+	concurrency := flags.Int("concurrency", 4, "number of concurrent transfers")
+	state := flags.String("state", "", "state file for remote to local sync")
+	flags.Var(&include, "include", "only sync matching paths, repeatable")
+	flags.Var(&exclude, "exclude", "skip matching paths, repeatable")
+	flags.Parse(args)
+	if err := needArgs(flags, 2); err != nil {
+		return err
+	}
+	src, dst := flags.Arg(0), flags.Arg(1)
+	opts := scs.SyncOptions{
+		Delete:      *del,
+		Include:     include,
+		Exclude:     exclude,
+		DryRun:      *dryRun,
+		Concurrency: *concurrency,
+	}
+	var report *scs.SyncReport
+	var err error
+	switch {
+	case !isRemote(src) && isRemote(dst):
+		b, prefix, berr := c.bucket(dst)
+		if berr != nil {
+			return berr
+		}
+		report, err = scs.Sync(context.Background(), src, b, prefix, &opts)
+	case isRemote(src) && !isRemote(dst):
+		b, prefix, berr := c.bucket(src)
+		if berr != nil {
+			return berr
+		}
+		report, err = scs.Mirror(context.Background(), b, prefix, dst, &scs.MirrorOptions{SyncOptions: opts, StateFile: *state})
+	default:
+		return errors.New("sync needs one local and one scs:// path")
+	}
+	if report != nil {
+		rows := make([][]string, 0, len(report.Actions))
+		for _, a := range report.Actions {
+			status := "ok"
+			if a.Err != nil {
+				status = a.Err.Error()
+			}
+			rows = append(rows, []string{a.Op, a.Key, fmt.Sprint(a.Size), status})
+		}
+		if perr := c.print(report, []string{"OP", "KEY", "SIZE", "STATUS"}, rows); perr != nil {
+			return perr
+		}
+		if c.output == "table" {
+			fmt.Printf("uploaded %d, relaxed %d, updated %d, downloaded %d, deleted %d, skipped %d, failed %d, %d bytes\n",
+				report.Uploaded, report.Relaxed, report.Updated, report.Downloaded, report.Deleted, report.Skipped, report.Failed, report.Bytes)
+		}
+	}
+	return err
+}
+
+func cmdPresign(c *cli, args []string) error {
+	flags := flag.NewFlagSet("presign", flag.ExitOnError)
+	expires := flags.Duration("expires", time.Hour, "url lifetime")
+	flags.Parse(args)
+	if err := needArgs(flags, 1); err != nil {
+		return err
+	}
+	b, key, err := c.bucket(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	u, err := b.Presign(key, *expires)
+	if err != nil {
+		return err
+	}
+	return c.print(map[string]string{"url": u}, nil, [][]string{{u}})
+}
+
+func cmdACL(c *cli, args []string) error {
+	flags := flag.NewFlagSet("acl", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		return errors.New("usage: scs acl get|set scs://bucket[/key] [json]")
+	}
+	name, key, err := splitRemote(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	switch flags.Arg(0) {
+	case "get":
+		var info scs.ACLInfo
+		if key == "" {
+			info, err = c.s.GetBucketACL(name)
+		} else {
+			var b *scs.Bucket
+			if b, _, err = c.bucket(flags.Arg(1)); err == nil {
+				info, err = b.GetACL(key)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(info.ACL))
+		for grantee, perms := range info.ACL {
+			rows = append(rows, []string{grantee, strings.Join(perms, ",")})
+		}
+		return c.print(info, []string{"GRANTEE", "PERMISSIONS"}, rows)
+	case "set":
+		if flags.NArg() != 3 {
+			return errors.New("usage: scs acl set scs://bucket[/key] json")
+		}
+		var acl scs.ACL
+		if err := json.Unmarshal([]byte(flags.Arg(2)), &acl); err != nil {
+			return fmt.Errorf("bad acl json: %v", err)
+		}
+		if key == "" {
+			return c.s.PutBucketACL(name, acl)
+		}
+		b, _, err := c.bucket(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		return b.PutACL(key, acl)
+	default:
+		return fmt.Errorf("unknown acl command %q", flags.Arg(0))
+	}
+}
+
+func cmdMultipart(c *cli, args []string) error {
+	flags := flag.NewFlagSet("multipart", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		return errors.New("usage: scs multipart list|abort scs://bucket[/key] [uploadId]")
+	}
+	b, key, err := c.bucket(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	switch flags.Arg(0) {
+	case "list":
+		var uploads []scs.Upload
+		keyMarker, uploadIDMarker := "", ""
+		for {
+			lmu, err := b.ListMultipartUploads(key, keyMarker, uploadIDMarker, 1000)
+			if err != nil {
+				return err
+			}
+			uploads = append(uploads, lmu.Uploads...)
+			if !lmu.IsTruncated || (lmu.NextKeyMarker == "" && lmu.NextUploadIDMarker == "") {
+				break
+			}
+			keyMarker, uploadIDMarker = lmu.NextKeyMarker, lmu.NextUploadIDMarker
+		}
+		rows := make([][]string, 0, len(uploads))
+		for _, u := range uploads {
+			rows = append(rows, []string{u.Initiated, u.UploadID, u.Key})
+		}
+		return c.print(uploads, []string{"INITIATED", "UPLOAD-ID", "KEY"}, rows)
+	case "abort":
+		if flags.NArg() != 3 {
+			return errors.New("usage: scs multipart abort scs://bucket/key uploadId")
+		}
+		return b.AbortMultipartUpload(key, flags.Arg(2))
+	default:
+		return fmt.Errorf("unknown multipart command %q", flags.Arg(0))
+	}
+}
@@ -0,0 +1,236 @@
+// Command scs is a command line client for SCS built on the scs package.
+//
+// Credentials are read from the SCS_ACCESS_KEY, SCS_SECRET_KEY and
+// SCS_ENDPOINT environment variables, or from a profile in the JSON config
+// file (default ~/.scs/config.json):
+//
+//	{
+//	    "default": {
+//	        "accesskey": "accesskey",
+//	        "secretkey": "secretkey",
+//	        "endpoint": "https://sinacloud.net"
+//	    }
+//	}
+//
+// Remote paths are written as scs://bucket/key.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Arvintian/scs-go-sdk/scs"
+)
+
+const remoteScheme = "scs://"
+
+const usage = `usage: scs [-profile name] [-config file] [-o table|json] <command> [args]
+
+commands:
+  ls        [-r] [scs://bucket[/prefix]]      list buckets or objects
+  stat      scs://bucket/key                  show object meta
+  cat       scs://bucket/key                  write object to stdout
+  cp        src dst                           copy local<->remote or remote<->remote
+  mv        src dst                           move local<->remote or remote<->remote
+  rm        [-r] scs://bucket/key             remove object, or a prefix with -r
+            -r -force name scs://bucket       remove all objects, name must match the bucket
+  mb        [-acl acl] scs://bucket           make bucket
+  rb        [-force name] scs://bucket        remove bucket, emptying it first with -force
+  sync      [flags] src dst                   sync a local dir and a remote prefix
+  presign   [-expires d] scs://bucket/key     print a presigned download url
+  acl       get|set scs://bucket[/key] [json] show or set acl
+  multipart list|abort scs://bucket[/key] [uploadId]
+`
+
+// profile 配置文件中的一组凭证
+type profile struct {
+	AccessKey string `json:"accesskey"`
+	SecretKey string `json:"secretkey"`
+	Endpoint  string `json:"endpoint"`
+}
+
+// cli 命令执行上下文
+type cli struct {
+	s      *scs.SCS
+	output string
+}
+
+type command func(c *cli, args []string) error
+
+var commands = map[string]command{
+	"ls":        cmdLs,
+	"stat":      cmdStat,
+	"cat":       cmdCat,
+	"cp":        cmdCp,
+	"mv":        cmdMv,
+	"rm":        cmdRm,
+	"mb":        cmdMb,
+	"rb":        cmdRb,
+	"sync":      cmdSync,
+	"presign":   cmdPresign,
+	"acl":       cmdACL,
+	"multipart": cmdMultipart,
+}
+
+func main() {
+	flags := flag.NewFlagSet("scs", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	profileName := flags.String("profile", os.Getenv("SCS_PROFILE"), "config profile")
+	configFile := flags.String("config", "", "config file")
+	output := flags.String("o", "table", "output format, table or json")
+	flags.Parse(os.Args[1:])
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "scs: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "scs: unknown output format %q\n", *output)
+		os.Exit(2)
+	}
+	p, err := loadProfile(*configFile, *profileName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "scs:", err)
+		os.Exit(1)
+	}
+	s, err := scs.NewSCS(p.AccessKey, p.SecretKey, p.Endpoint)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "scs:", err)
+		os.Exit(1)
+	}
+	if err := cmd(&cli{s: s, output: *output}, flags.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "scs:", err)
+		os.Exit(1)
+	}
+}
+
+// loadProfile 从环境变量或配置文件读取凭证，环境变量优先
+func loadProfile(configFile, name string) (profile, error) {
+	p := profile{
+		AccessKey: os.Getenv("SCS_ACCESS_KEY"),
+		SecretKey: os.Getenv("SCS_SECRET_KEY"),
+		Endpoint:  os.Getenv("SCS_ENDPOINT"),
+	}
+	if p.AccessKey != "" && p.SecretKey != "" && name == "" {
+		if p.Endpoint == "" {
+			p.Endpoint = "https://sinacloud.net"
+		}
+		return p, nil
+	}
+	if name == "" {
+		name = "default"
+	}
+	if configFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return p, err
+		}
+		configFile = filepath.Join(home, ".scs", "config.json")
+	}
+	bts, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return p, fmt.Errorf("no credentials: set SCS_ACCESS_KEY and SCS_SECRET_KEY or create %s", configFile)
+	}
+	profiles := make(map[string]profile)
+	if err := json.Unmarshal(bts, &profiles); err != nil {
+		return p, fmt.Errorf("bad config file %s: %v", configFile, err)
+	}
+	cp, ok := profiles[name]
+	if !ok {
+		return p, fmt.Errorf("profile %q not found in %s", name, configFile)
+	}
+	if cp.Endpoint == "" {
+		cp.Endpoint = "https://sinacloud.net"
+	}
+	return cp, nil
+}
+
+// isRemote 判断是否为scs://路径
+func isRemote(p string) bool {
+	return strings.HasPrefix(p, remoteScheme)
+}
+
+// splitRemote 将scs://bucket/key拆分为bucket和key
+func splitRemote(p string) (string, string, error) {
+	if !isRemote(p) {
+		return "", "", fmt.Errorf("not a remote path: %s", p)
+	}
+	rest := strings.TrimPrefix(p, remoteScheme)
+	parts := strings.SplitN(rest, "/", 2)
+	if parts[0] == "" {
+		return "", "", fmt.Errorf("missing bucket in %s", p)
+	}
+	if len(parts) == 1 {
+		return parts[0], "", nil
+	}
+	return parts[0], parts[1], nil
+}
+
+// bucket 获取scs://路径对应的bucket
+func (c *cli) bucket(p string) (*scs.Bucket, string, error) {
+	name, key, err := splitRemote(p)
+	if err != nil {
+		return nil, "", err
+	}
+	return c.s.Bucket(name), key, nil
+}
+
+// object 获取scs://路径对应的bucket和key，key为空时返回错误，避免对bucket本身发出请求
+func (c *cli) object(p string) (*scs.Bucket, string, error) {
+	b, key, err := c.bucket(p)
+	if err != nil {
+		return nil, "", err
+	}
+	if key == "" {
+		return nil, "", fmt.Errorf("missing key in %s", p)
+	}
+	return b, key, nil
+}
+
+// print 按输出格式打印，table格式时调用table写入各行
+func (c *cli) print(v interface{}, header []string, rows [][]string) error {
+	if c.output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// stringsFlag 可重复的字符串参数
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func needArgs(flags *flag.FlagSet, n int) error {
+	if flags.NArg() != n {
+		return errors.New("wrong number of arguments, see scs -h")
+	}
+	return nil
+}
@@ -1,3 +1,3 @@
 module github.com/Arvintian/scs-go-sdk
 
-go 1.13
+go 1.16
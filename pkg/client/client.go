@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,6 +12,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -20,6 +22,13 @@ type Client struct {
 	accesskey string
 	secretkey string
 	hc        *http.Client
+	//bandwidth limits, read for downloads and write for uploads
+	readLimiter  *RateLimiter
+	writeLimiter *RateLimiter
+	//requests per second and in flight limits
+	requests *requestLimiter
+	//subresources the endpoint does not implement, see SetUnsupported
+	unsupported *sync.Map
 }
 
 //Request scs http request
@@ -30,6 +39,14 @@ type Request struct {
 	Params  url.Values
 	Headers http.Header
 	Body    io.Reader
+	Context context.Context
+	//SendProgress is called with the number of request body bytes sent
+	SendProgress func(n int64)
+	//RecvProgress is called with the number of response body bytes received
+	RecvProgress func(n int64)
+	//ReadLimiter and WriteLimiter override the client bandwidth limits
+	ReadLimiter  *RateLimiter
+	WriteLimiter *RateLimiter
 	//internal
 	baseuri  string
 	signpath string
@@ -38,9 +55,16 @@ type Request struct {
 
 var sigleParams = map[string]bool{
 	"acl":       true,
+	"copy":      true,
+	"cors":      true,
+	"delete":    true,
+	"lifecycle": true,
+	"logging":   true,
 	"meta":      true,
 	"multipart": true,
+	"referer":   true,
 	"relax":     true,
+	"website":   true,
 }
 
 // HTTPTimeout defines HTTP timeout.
@@ -72,9 +96,13 @@ func NewClient(accesskey, secretkey, endpoint string) *Client {
 	httpMaxConns.MaxIdleConnsPerHost = 100
 
 	return &Client{
-		accesskey: accesskey,
-		secretkey: secretkey,
-		endpoint:  endpoint,
+		accesskey:    accesskey,
+		secretkey:    secretkey,
+		endpoint:     endpoint,
+		readLimiter:  NewRateLimiter(0),
+		writeLimiter: NewRateLimiter(0),
+		requests:     newRequestLimiter(),
+		unsupported:  &sync.Map{},
 		hc: &http.Client{
 			Transport: &http.Transport{
 				Dial: func(netw, addr string) (net.Conn, error) {
@@ -97,17 +125,80 @@ func NewClient(accesskey, secretkey, endpoint string) *Client {
 	}
 }
 
-//Query scs http query
+//AccessKey return the client accesskey
+func (c *Client) AccessKey() string {
+	return c.accesskey
+}
+
+//Endpoint return the client endpoint
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+//Unsupported report whether feature was marked as not implemented by the endpoint
+func (c *Client) Unsupported(feature string) bool {
+	_, ok := c.unsupported.Load(feature)
+	return ok
+}
+
+//SetUnsupported mark feature, such as a subresource, as not implemented by the endpoint,
+//so callers can fall back without trying it again
+func (c *Client) SetUnsupported(feature string) {
+	c.unsupported.Store(feature, true)
+}
+
+//SetRateLimit set the bandwidth limits in bytes per second for downloads(read) and uploads(write),
+//0 means unlimited, it can be called at runtime
+func (c *Client) SetRateLimit(read, write int64) {
+	c.readLimiter.SetLimit(read)
+	c.writeLimiter.SetLimit(write)
+}
+
+//SetRequestLimit set the max requests per second and the max requests in flight,
+//0 means unlimited, it can be called at runtime. A request is in flight until its response headers are received.
+func (c *Client) SetRequestLimit(perSecond float64, maxInFlight int) {
+	c.requests.setLimit(perSecond, maxInFlight)
+}
+
+//Query scs http query, it waits for the request limits and backs off when the server throttles,
+//throttled requests without body are retried
 func (c *Client) Query(req *Request) (http.Header, io.ReadCloser, error) {
+	ctx := req.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for retry := 0; ; retry++ {
+		err := c.prepare(req)
+		if err != nil {
+			return nil, ioutil.NopCloser(bytes.NewBuffer([]byte{})), err
+		}
+		if err = c.requests.acquire(ctx); err != nil {
+			return nil, ioutil.NopCloser(bytes.NewBuffer([]byte{})), err
+		}
+		hresp, err := c.run(req)
+		c.requests.release()
+		if e, ok := err.(*Error); ok && e.throttled() && req.Body == nil && retry < maxThrottleRetries {
+			continue
+		}
+		if err != nil || hresp == nil {
+			return nil, ioutil.NopCloser(bytes.NewBuffer([]byte{})), err
+		}
+		return hresp.Header, hresp.Body, nil
+	}
+}
+
+//SignURL return a presigned url of the request, req.Params must contain Expires
+func (c *Client) SignURL(req *Request) (string, error) {
 	err := c.prepare(req)
 	if err != nil {
-		return nil, ioutil.NopCloser(bytes.NewBuffer([]byte{})), err
+		return "", err
 	}
-	hresp, err := c.run(req)
-	if err != nil || hresp == nil {
-		return nil, ioutil.NopCloser(bytes.NewBuffer([]byte{})), err
+	u, err := req.urlencode()
+	if err != nil {
+		return "", err
 	}
-	return hresp.Header, hresp.Body, nil
+	// u.Opaque is the escaped path, so build the url by hand
+	return u.Scheme + "://" + u.Host + u.Opaque + "?" + u.RawQuery, nil
 }
 
 func (c *Client) prepare(req *Request) error {
@@ -172,15 +263,47 @@ func (c *Client) run(req *Request) (hresp *http.Response, err error) {
 		delete(req.Headers, "Content-Length")
 	}
 	htCli := c.hc
+	ctx := req.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	if req.Body != nil {
-		hreq.Body = ioutil.NopCloser(req.Body)
+		var body = req.Body
+		writeLimiter := req.WriteLimiter
+		if writeLimiter == nil {
+			writeLimiter = c.writeLimiter
+		}
+		body = &rateLimitedReader{r: body, l: writeLimiter, ctx: ctx}
+		if req.SendProgress != nil {
+			body = &progressReader{r: body, fn: req.SendProgress}
+		}
+		hreq.Body = ioutil.NopCloser(body)
 	}
-	hresp, err = htCli.Do(&hreq)
+	hr := &hreq
+	if req.Context != nil {
+		hr = hr.WithContext(req.Context)
+	}
+	hresp, err = htCli.Do(hr)
 	if err != nil {
 		return nil, err
 	}
 	if hresp.StatusCode != 200 && hresp.StatusCode != 204 && hresp.StatusCode != 206 {
-		return nil, buildError(hresp)
+		err = buildError(hresp)
+		if err.(*Error).throttled() {
+			c.requests.throttled()
+		} else {
+			c.requests.succeeded()
+		}
+		return nil, err
+	}
+	c.requests.succeeded()
+	readLimiter := req.ReadLimiter
+	if readLimiter == nil {
+		readLimiter = c.readLimiter
+	}
+	hresp.Body = &rateLimitedReadCloser{rateLimitedReader: rateLimitedReader{r: hresp.Body, l: readLimiter, ctx: ctx}, c: hresp.Body}
+	if req.RecvProgress != nil {
+		hresp.Body = &progressReadCloser{ReadCloser: hresp.Body, fn: req.RecvProgress}
 	}
 	return hresp, nil
 }
@@ -218,6 +341,12 @@ func (req *Request) urlencode() (*url.URL, error) {
 	return u, nil
 }
 
+// QuotePath escapes each segment of p the same way request paths are escaped,
+// for keys carried in headers such as x-amz-copy-source
+func QuotePath(p string) string {
+	return urlquote(p)
+}
+
 //https://scs.sinacloud.com/doc/scs/guide#limitations
 //https://github.com/SinaCloudStorage/SinaStorage-SDK-Python/blob/2192dc3cb76fb792986242bf7b65e24bda5333b8/sinastorage/utils.py#L135
 func urlquote(u string) string {
@@ -240,15 +369,21 @@ func (e *Error) Error() string {
 	return e.ErrorCode
 }
 
+// throttled report whether the server throttled the request
+func (e *Error) throttled() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable || throttleCodes[e.ErrorCode]
+}
+
 func buildError(r *http.Response) error {
+	defer r.Body.Close()
 	var err Error
 	err.StatusCode = r.StatusCode
-	err.RequestID = r.Header["X-Requestid"][0]
-	if ErrCode, ok := r.Header["X-Error-Code"]; ok {
-		err.ErrorCode = ErrCode[0]
+	err.RequestID = r.Header.Get("X-Requestid")
+	if ErrCode := r.Header.Get("X-Error-Code"); ErrCode != "" {
+		err.ErrorCode = ErrCode
 	} else {
 		err.ErrorCode = strconv.FormatInt(int64(r.StatusCode), 10)
 	}
-	err.Date = r.Header["Date"][0]
+	err.Date = r.Header.Get("Date")
 	return &err
 }
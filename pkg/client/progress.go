@@ -0,0 +1,31 @@
+package client
+
+import "io"
+
+// progressReader calls fn with the number of bytes read
+type progressReader struct {
+	r  io.Reader
+	fn func(n int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.fn(int64(n))
+	}
+	return n, err
+}
+
+// progressReadCloser calls fn with the number of bytes read
+type progressReadCloser struct {
+	io.ReadCloser
+	fn func(n int64)
+}
+
+func (p *progressReadCloser) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	if n > 0 {
+		p.fn(int64(n))
+	}
+	return n, err
+}
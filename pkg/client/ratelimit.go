@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// rateLimitChunk max bytes taken from the limiter by one read
+const rateLimitChunk = 32 * 1024
+
+// RateLimiter token bucket limiting bytes per second, safe for concurrent use.
+// The limit can be changed at runtime, a limit <= 0 means unlimited.
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  int64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter return a limiter allowing bytesPerSec bytes per second
+func NewRateLimiter(bytesPerSec int64) *RateLimiter {
+	return &RateLimiter{limit: bytesPerSec, last: time.Now()}
+}
+
+// SetLimit change the limit in bytes per second
+func (l *RateLimiter) SetLimit(bytesPerSec int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.limit = bytesPerSec
+	if l.tokens > float64(bytesPerSec) {
+		l.tokens = float64(bytesPerSec)
+	}
+}
+
+// Limit return the limit in bytes per second
+func (l *RateLimiter) Limit() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// WaitN block until n bytes can be transferred or ctx is done
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	if l.limit <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	l.refill(now)
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / float64(l.limit) * float64(time.Second))
+	}
+	l.mu.Unlock()
+	return sleep(ctx, wait)
+}
+
+// refill add tokens for the time passed, the burst is one second of traffic
+func (l *RateLimiter) refill(now time.Time) {
+	if l.limit > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.limit)
+		if l.tokens > float64(l.limit) {
+			l.tokens = float64(l.limit)
+		}
+	}
+	l.last = now
+}
+
+// rateLimitedReader limit the bytes read per second
+type rateLimitedReader struct {
+	r   io.Reader
+	l   *RateLimiter
+	ctx context.Context
+}
+
+func (r *rateLimitedReader) Read(b []byte) (int, error) {
+	if len(b) > rateLimitChunk {
+		b = b[:rateLimitChunk]
+	}
+	n, err := r.r.Read(b)
+	if n > 0 {
+		if werr := r.l.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// rateLimitedReadCloser limit the bytes read per second
+type rateLimitedReadCloser struct {
+	rateLimitedReader
+	c io.Closer
+}
+
+func (r *rateLimitedReadCloser) Close() error {
+	return r.c.Close()
+}
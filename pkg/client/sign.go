@@ -18,10 +18,15 @@ var s3ParamsToSign = map[string]bool{
 	"uploads":    true,
 	"part":       true,
 	"copy":       true,
+	"cors":       true,
+	"delete":     true,
+	"lifecycle":  true,
 	"multipart":  true,
 	"partNumber": true,
 	"uploadId":   true,
 	"ip":         true,
+	"referer":    true,
+	"website":    true,
 }
 
 func sign(c Client, method, canonicalizedResource string, parmams, headers map[string][]string) {
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// minBackoff first delay applied after a throttling response
+	minBackoff = 100 * time.Millisecond
+	// maxBackoff upper bound of the adaptive backoff
+	maxBackoff = 10 * time.Second
+	// maxThrottleRetries times a request without body is retried after throttling
+	maxThrottleRetries = 3
+)
+
+// throttleCodes error codes returned by the server when requests are throttled
+var throttleCodes = map[string]bool{
+	"SlowDown":             true,
+	"Throttling":           true,
+	"ThrottlingException":  true,
+	"TooManyRequests":      true,
+	"RequestLimitExceeded": true,
+}
+
+// requestLimiter limits requests per second and requests in flight,
+// and delays requests adaptively after the server throttled us
+type requestLimiter struct {
+	mu sync.Mutex
+	// requests per second, <= 0 means unlimited
+	rate   float64
+	tokens float64
+	last   time.Time
+	// max requests in flight, <= 0 means unlimited
+	maxInFlight int
+	inFlight    int
+	// closed and replaced when a slot is released or the limits change
+	changed chan struct{}
+	// delay before each request, doubled on throttling and halved on success
+	backoff time.Duration
+}
+
+func newRequestLimiter() *requestLimiter {
+	return &requestLimiter{last: time.Now(), changed: make(chan struct{})}
+}
+
+// setLimit change the limits, wake up the waiting requests
+func (l *requestLimiter) setLimit(rate float64, maxInFlight int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.rate = rate
+	if l.tokens > l.burst() {
+		l.tokens = l.burst()
+	}
+	l.maxInFlight = maxInFlight
+	l.notify()
+}
+
+// acquire block until the request can be sent or ctx is done,
+// release must be called when acquire returns nil
+func (l *requestLimiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.maxInFlight <= 0 || l.inFlight < l.maxInFlight {
+			l.inFlight++
+			l.mu.Unlock()
+			break
+		}
+		changed := l.changed
+		l.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	l.mu.Lock()
+	wait := l.backoff
+	if l.rate > 0 {
+		now := time.Now()
+		l.refill(now)
+		l.tokens--
+		if l.tokens < 0 {
+			if d := time.Duration(-l.tokens / l.rate * float64(time.Second)); d > wait {
+				wait = d
+			}
+		}
+	}
+	l.mu.Unlock()
+	if err := sleep(ctx, wait); err != nil {
+		l.release()
+		return err
+	}
+	return nil
+}
+
+// release free the in flight slot taken by acquire
+func (l *requestLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.notify()
+}
+
+// throttled grow the backoff after a throttling response
+func (l *requestLimiter) throttled() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.backoff < minBackoff {
+		l.backoff = minBackoff
+	} else if l.backoff *= 2; l.backoff > maxBackoff {
+		l.backoff = maxBackoff
+	}
+}
+
+// succeeded shrink the backoff after a response which was not throttled
+func (l *requestLimiter) succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.backoff /= 2; l.backoff < minBackoff {
+		l.backoff = 0
+	}
+}
+
+func (l *requestLimiter) burst() float64 {
+	if l.rate < 1 {
+		return 1
+	}
+	return l.rate
+}
+
+func (l *requestLimiter) refill(now time.Time) {
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst() {
+			l.tokens = l.burst()
+		}
+	}
+	l.last = now
+}
+
+func (l *requestLimiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// sleep wait d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -38,7 +38,7 @@ func main() {
 
 	// fmt.Println("test list get bucket ============")
 	// fmt.Println(s.ListBuckets())
-	b, _ := s.GetBucket(bucket)
+	b := s.Bucket(bucket)
 	// // fmt.Println(s.PutBucket("test"))
 	// // fmt.Println(s.GetBucketACL("test"))
 	// // fmt.Println(s.PutBucketACL("test"))
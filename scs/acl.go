@@ -0,0 +1,89 @@
+package scs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// GetBucketACL 获取bucket acl
+func (s *SCS) GetBucketACL(name string) (ACLInfo, error) {
+	return getACL(s.c, name, "/")
+}
+
+// PutBucketACL 指定Bucket设置ACL规则
+func (s *SCS) PutBucketACL(name string, acl ACL) error {
+	return putACL(s.c, name, "/", acl)
+}
+
+// GetACL 获取object acl
+func (b *Bucket) GetACL(key string) (ACLInfo, error) {
+	return getACL(b.c, b.Name, fmt.Sprintf("/%s", key))
+}
+
+// PutACL 设置object acl
+func (b *Bucket) PutACL(key string, acl ACL) error {
+	return putACL(b.c, b.Name, fmt.Sprintf("/%s", key), acl)
+}
+
+func getACL(c *client.Client, bucket, path string) (ACLInfo, error) {
+	var info ACLInfo
+	var params = make(map[string][]string)
+	params["acl"] = []string{""}
+	params["formatter"] = []string{"json"}
+	req := &client.Request{
+		Method: "GET",
+		Bucket: bucket,
+		Path:   path,
+		Params: params,
+	}
+	_, rc, err := c.Query(req)
+	defer rc.Close()
+	if err != nil {
+		return info, err
+	}
+	bts, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(bts, &info); err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+func putACL(c *client.Client, bucket, path string, acl ACL) error {
+	var params = make(map[string][]string)
+	params["acl"] = []string{""}
+	params["formatter"] = []string{"json"}
+	bts, err := json.Marshal(acl)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(bts)
+	var headers = make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	headers.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	headers.Set("Content-Length", strconv.Itoa(len(bts)))
+	req := &client.Request{
+		Method:  "PUT",
+		Bucket:  bucket,
+		Path:    path,
+		Params:  params,
+		Headers: headers,
+		Body:    bytes.NewReader(bts),
+	}
+	_, body, err := c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
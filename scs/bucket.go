@@ -2,14 +2,16 @@ package scs
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Arvintian/scs-go-sdk/pkg/client"
 )
@@ -22,19 +24,26 @@ func (b *Bucket) String() string {
 GetObject和MultipartUpload没实现数据完整性校验
 **********************************************/
 
-// Head 获取object meta
+// ErrObjectNotFound Head的object不存在
+var ErrObjectNotFound = errors.New("object not found")
+
+// Head 获取object meta，object不存在时返回ErrObjectNotFound
 func (b *Bucket) Head(key string) (ObjectMeta, error) {
-	var m ObjectMeta
-	var params = make(map[string][]string)
-	params["formatter"] = []string{"json"}
-	req := &client.Request{
-		Method: "HEAD",
-		Bucket: b.Name,
-		Path:   fmt.Sprintf("/%s", key),
-		Params: params,
+	return b.head(context.Background(), key)
+}
+
+// Exists 判断object是否存在
+func (b *Bucket) Exists(key string) (bool, error) {
+	_, err := b.head(context.Background(), key)
+	if err == ErrObjectNotFound {
+		return false, nil
 	}
-	header, body, err := b.c.Query(req)
-	defer body.Close()
+	return err == nil, err
+}
+
+func (b *Bucket) head(ctx context.Context, key string) (ObjectMeta, error) {
+	var m ObjectMeta
+	header, err := b.headHeader(ctx, key)
 	if err != nil {
 		return m, err
 	}
@@ -44,88 +53,234 @@ func (b *Bucket) Head(key string) (ObjectMeta, error) {
 	if err != nil {
 		length, err = strconv.ParseInt(header.Get("X-Filesize"), 10, 0)
 		if err != nil {
-			return m, err
+			length = -1
 		}
 	}
 	m.ContentLength = length
 	m.ETag = header.Get("ETag")
 	m.LastModified = header.Get("Last-Modified")
+	m.CacheControl = header.Get("Cache-Control")
+	m.ContentEncoding = header.Get("Content-Encoding")
+	m.ContentDisposition = header.Get("Content-Disposition")
 	m.XAmzMeta = make(map[string]string)
+	m.Metadata = make(map[string]string)
 	for k, v := range header {
-		if strings.HasPrefix(k, "X-Amz-Meta-") {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-meta-") {
+			var value string
 			if len(v) > 0 {
-				m.XAmzMeta[k] = v[0]
-			} else {
-				m.XAmzMeta[k] = ""
-			}
-		} else {
-			if strings.HasPrefix(k, "x-amz-meta-") {
-				if len(v) > 0 {
-					m.XAmzMeta[k] = v[0]
-				} else {
-					m.XAmzMeta[k] = ""
-				}
+				value = v[0]
 			}
+			m.XAmzMeta[k] = value
+			m.Metadata[strings.ToLower(k[len("x-amz-meta-"):])] = value
 		}
 	}
 	return m, nil
 }
 
-// Get 获取object
-func (b *Bucket) Get(key string, rg string) (io.ReadCloser, error) {
+// headHeader 返回HEAD请求的响应头，object不存在时返回ErrObjectNotFound
+func (b *Bucket) headHeader(ctx context.Context, key string) (http.Header, error) {
 	var params = make(map[string][]string)
-	var headers = make(http.Header)
 	params["formatter"] = []string{"json"}
-	if rg != "" {
-		headers.Set("Range", fmt.Sprintf("bytes=%s", rg))
-	}
-	headers.Set("Accept-Encoding", "identity")
 	req := &client.Request{
-		Method:  "GET",
+		Method:  "HEAD",
 		Bucket:  b.Name,
 		Path:    fmt.Sprintf("/%s", key),
 		Params:  params,
-		Headers: headers,
+		Context: ctx,
 	}
-	_, data, err := b.c.Query(req)
+	header, body, err := b.c.Query(req)
+	defer body.Close()
 	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrObjectNotFound
+		}
 		return nil, err
 	}
+	return header, nil
+}
+
+// stat 获取object meta，Head没有返回长度时通过Range为0-0的请求从Content-Range中得到object大小，仍无法确定时返回错误
+func (b *Bucket) stat(ctx context.Context, key string) (ObjectMeta, error) {
+	m, err := b.head(ctx, key)
+	if err != nil || m.ContentLength >= 0 {
+		return m, err
+	}
+	var headers = make(http.Header)
+	if m.ETag != "" {
+		headers.Set("If-Match", m.ETag)
+	}
+	header, body, err := b.getObject(ctx, key, "0-0", headers)
+	if err != nil {
+		if e, ok := err.(*client.Error); ok && e.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			// 只有空object不能满足0-0
+			m.ContentLength = 0
+			return m, nil
+		}
+		if isNotFound(err) {
+			return m, ErrObjectNotFound
+		}
+		return m, err
+	}
+	body.Close()
+	if v := header.Get("Content-Range"); v != "" {
+		if _, _, total, err := parseContentRange(v); err == nil && total >= 0 {
+			m.ContentLength = total
+			return m, nil
+		}
+	} else if length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		// 忽略Range时返回整个object
+		m.ContentLength = length
+		return m, nil
+	}
+	return m, fmt.Errorf("unknown length of object %s", key)
+}
+
+// Get 获取object，通过WithCompression上传的object在获取整个object时自动解压，
+// 指定rg时按压缩后的字节范围返回原始字节，不解压
+func (b *Bucket) Get(key string, rg string, opts ...Option) (io.ReadCloser, error) {
+	o := newOptions(opts)
+	tracker := newProgressTracker(o.progress, key, -1)
+	tracker.started()
+	header, data, err := b.getObject(withProgress(o.context(context.Background()), tracker), key, rg, nil)
+	if err != nil {
+		return nil, tracker.finish(err)
+	}
+	if length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		tracker.setTotal(length)
+	}
+	if tracker != nil {
+		data = &progressBody{ReadCloser: data, t: tracker}
+	}
+	if rg == "" {
+		rc, err := decompressBody(header, data)
+		if err != nil {
+			data.Close()
+			return nil, tracker.finish(err)
+		}
+		return rc, nil
+	}
 	return data, nil
 }
 
-// Put 上传object
-func (b *Bucket) Put(key string, XAmzMeta map[string]string, data io.Reader) error {
+func (b *Bucket) getObject(ctx context.Context, key string, rg string, headers http.Header) (http.Header, io.ReadCloser, error) {
 	var params = make(map[string][]string)
-	var headers = make(http.Header)
+	if headers == nil {
+		headers = make(http.Header)
+	}
 	params["formatter"] = []string{"json"}
-	for k, v := range XAmzMeta {
-		headers.Set(k, v)
+	if rg != "" {
+		headers.Set("Range", fmt.Sprintf("bytes=%s", rg))
+	}
+	headers.Set("Accept-Encoding", "identity")
+	req := &client.Request{
+		Method:       "GET",
+		Bucket:       b.Name,
+		Path:         fmt.Sprintf("/%s", key),
+		Params:       params,
+		Headers:      headers,
+		Context:      ctx,
+		RecvProgress: progressFromContext(ctx).countFunc(),
+	}
+	req.ReadLimiter, _ = limitersFromContext(ctx)
+	header, data, err := b.c.Query(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, data, nil
+}
+
+// Put 上传object，data可以是任意reader，长度未知时先在内存中缓冲，超过MD5Threshold自动切换为分片上传
+func (b *Bucket) Put(key string, XAmzMeta map[string]string, data io.Reader, opts ...Option) error {
+	o := newOptions(opts)
+	length, err := GetReaderLen(data)
+	if err != nil || o.codec != "" || !isBuffered(data) {
+		length = -1
+	}
+	tracker := newProgressTracker(o.progress, key, length)
+	tracker.started()
+	return tracker.finish(b.put(withProgress(o.context(context.Background()), tracker), key, XAmzMeta, data, o))
+}
+
+func (b *Bucket) put(ctx context.Context, key string, XAmzMeta map[string]string, data io.Reader, o *options) error {
+	if o.codec != "" {
+		c, err := getCodec(o.codec)
+		if err != nil {
+			return err
+		}
+		size, err := GetReaderLen(data)
+		if err != nil || !isBuffered(data) {
+			size = -1
+		}
+		rc := compressReader(c, data)
+		defer rc.Close()
+		return b.putStream(ctx, key, compressMeta(c, XAmzMeta, size), rc)
+	}
+	if !isBuffered(data) {
+		return b.putStream(ctx, key, XAmzMeta, data)
 	}
 	length, err := GetReaderLen(data)
 	if err != nil {
 		return err
 	}
-	putData, md5, fd, err := calcMD5(data, length)
-	if fd != nil {
-		defer func() {
-			fd.Close()
-			os.Remove(fd.Name())
-		}()
+	return b.putObject(ctx, key, XAmzMeta, data, length)
+}
+
+func (b *Bucket) putObject(ctx context.Context, key string, XAmzMeta map[string]string, data io.Reader, length int64) error {
+	var params = make(map[string][]string)
+	var headers = make(http.Header)
+	params["formatter"] = []string{"json"}
+	for k, v := range XAmzMeta {
+		headers.Set(k, v)
 	}
+	putData, md5, err := calcMD5(data, length)
 	if err != nil {
 		return err
 	}
 	//fmt.Println(md5)
 	headers.Set("Content-Length", fmt.Sprint(length))
 	headers.Set("Content-MD5", md5)
+	req := &client.Request{
+		Method:       "PUT",
+		Bucket:       b.Name,
+		Path:         fmt.Sprintf("/%s", key),
+		Params:       params,
+		Headers:      headers,
+		Body:         putData,
+		Context:      ctx,
+		SendProgress: progressFromContext(ctx).countFunc(),
+	}
+	_, req.WriteLimiter = limitersFromContext(ctx)
+	_, body, err := b.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PutRelax 秒传object，服务端已存在SHA1和长度相同的内容时无需上传数据
+func (b *Bucket) PutRelax(key string, XAmzMeta map[string]string, sha1 string, length int64) error {
+	return b.putRelax(context.Background(), key, XAmzMeta, sha1, length)
+}
+
+func (b *Bucket) putRelax(ctx context.Context, key string, XAmzMeta map[string]string, sha1 string, length int64) error {
+	var params = make(map[string][]string)
+	var headers = make(http.Header)
+	params["formatter"] = []string{"json"}
+	params["relax"] = []string{""}
+	for k, v := range XAmzMeta {
+		headers.Set(k, v)
+	}
+	headers.Set("s-sina-sha1", sha1)
+	headers.Set("s-sina-length", fmt.Sprint(length))
+	headers.Set("Content-Length", "0")
 	req := &client.Request{
 		Method:  "PUT",
 		Bucket:  b.Name,
 		Path:    fmt.Sprintf("/%s", key),
 		Params:  params,
 		Headers: headers,
-		Body:    putData,
+		Context: ctx,
 	}
 	_, body, err := b.c.Query(req)
 	defer body.Close()
@@ -135,16 +290,68 @@ func (b *Bucket) Put(key string, XAmzMeta map[string]string, data io.Reader) err
 	return nil
 }
 
-// Delete 删除object
-func (b *Bucket) Delete(key string) error {
+// Copy 服务端复制srcBucket中的srcKey到当前bucket的key，源和目标需属于同一账号
+func (b *Bucket) Copy(key, srcBucket, srcKey string) error {
+	return b.copyObject(context.Background(), key, srcBucket, srcKey, nil)
+}
+
+// copyObject 服务端复制，XAmzMeta为nil时复制源object的meta，否则以XAmzMeta替换
+func (b *Bucket) copyObject(ctx context.Context, key, srcBucket, srcKey string, XAmzMeta map[string]string) error {
 	var params = make(map[string][]string)
+	var headers = make(http.Header)
 	params["formatter"] = []string{"json"}
+	params["copy"] = []string{""}
+	headers.Set("x-amz-copy-source", client.QuotePath(fmt.Sprintf("/%s/%s", srcBucket, srcKey)))
+	if XAmzMeta != nil {
+		headers.Set("x-amz-metadata-directive", "REPLACE")
+		for k, v := range XAmzMeta {
+			headers.Set(k, v)
+		}
+	}
 	req := &client.Request{
-		Method: "DELETE",
+		Method:  "PUT",
+		Bucket:  b.Name,
+		Path:    fmt.Sprintf("/%s", key),
+		Params:  params,
+		Headers: headers,
+		Context: ctx,
+	}
+	_, body, err := b.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Presign 生成object的预签名下载地址，expires后失效
+func (b *Bucket) Presign(key string, expires time.Duration) (string, error) {
+	var params = make(map[string][]string)
+	params["Expires"] = []string{fmt.Sprint(time.Now().Add(expires).Unix())}
+	req := &client.Request{
+		Method: "GET",
 		Bucket: b.Name,
 		Path:   fmt.Sprintf("/%s", key),
 		Params: params,
 	}
+	return b.c.SignURL(req)
+}
+
+// Delete 删除object
+func (b *Bucket) Delete(key string) error {
+	return b.deleteObject(context.Background(), key)
+}
+
+func (b *Bucket) deleteObject(ctx context.Context, key string) error {
+	var params = make(map[string][]string)
+	params["formatter"] = []string{"json"}
+	req := &client.Request{
+		Method:  "DELETE",
+		Bucket:  b.Name,
+		Path:    fmt.Sprintf("/%s", key),
+		Params:  params,
+		Context: ctx,
+	}
 	_, body, err := b.c.Query(req)
 	defer body.Close()
 	if err != nil {
@@ -190,8 +397,122 @@ func (b *Bucket) List(delimiter, prefix, marker string, limit int64) (ListObject
 	return lo, nil
 }
 
+// ListPages 分页列出prefix下的object和公共前缀，对每页结果调用fn，fn返回error时停止。
+// 服务端没有返回NextMarker时以本页最后一个key或公共前缀作为下一页的marker
+func (b *Bucket) ListPages(ctx context.Context, delimiter, prefix string, fn func(ListObject) error) error {
+	marker := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		lo, err := b.List(delimiter, prefix, marker, 1000)
+		if err != nil {
+			return err
+		}
+		if err := fn(lo); err != nil {
+			return err
+		}
+		if !lo.IsTruncated {
+			return nil
+		}
+		next := lo.NextMarker
+		if next == "" {
+			if n := len(lo.Contents); n > 0 {
+				next = lo.Contents[n-1].Name
+			}
+			if n := len(lo.CommonPrefixes); n > 0 && lo.CommonPrefixes[n-1].Prefix > next {
+				next = lo.CommonPrefixes[n-1].Prefix
+			}
+		}
+		if next == "" || next == marker {
+			return nil
+		}
+		marker = next
+	}
+}
+
+// walk 分页列出prefix下的所有object
+func (b *Bucket) walk(ctx context.Context, prefix string, fn func(Object) error) error {
+	return b.ListPages(ctx, "", prefix, func(lo ListObject) error {
+		for _, o := range lo.Contents {
+			if err := fn(o); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListMultipartUploads 列出进行中的分片上传
+func (b *Bucket) ListMultipartUploads(prefix, keyMarker, uploadIDMarker string, limit int64) (ListMultipartUpload, error) {
+	var lmu ListMultipartUpload
+	var params = make(map[string][]string)
+	params["formatter"] = []string{"json"}
+	params["multipart"] = []string{""}
+	if prefix != "" {
+		params["prefix"] = []string{prefix}
+	}
+	if keyMarker != "" {
+		params["key-marker"] = []string{keyMarker}
+	}
+	if uploadIDMarker != "" {
+		params["upload-id-marker"] = []string{uploadIDMarker}
+	}
+	params["max-uploads"] = []string{fmt.Sprint(limit)}
+	req := &client.Request{
+		Method: "GET",
+		Bucket: b.Name,
+		Path:   "/",
+		Params: params,
+	}
+	_, body, err := b.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return lmu, err
+	}
+	bts, err := ioutil.ReadAll(body)
+	if err != nil {
+		return lmu, err
+	}
+	if err := json.Unmarshal(bts, &lmu); err != nil {
+		return lmu, err
+	}
+	return lmu, nil
+}
+
+// walkUploads 分页列出prefix下进行中的分片上传，对每个upload调用fn
+func (b *Bucket) walkUploads(ctx context.Context, prefix string, fn func(Upload) error) error {
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		lmu, err := b.ListMultipartUploads(prefix, keyMarker, uploadIDMarker, 1000)
+		if err != nil {
+			return err
+		}
+		for _, u := range lmu.Uploads {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+		if !lmu.IsTruncated || len(lmu.Uploads) == 0 {
+			return nil
+		}
+		keyMarker, uploadIDMarker = lmu.NextKeyMarker, lmu.NextUploadIDMarker
+		if keyMarker == "" {
+			last := lmu.Uploads[len(lmu.Uploads)-1]
+			keyMarker, uploadIDMarker = last.Key, last.UploadID
+		}
+	}
+}
+
 // InitiateMultipartUpload 大文件分片上传
 func (b *Bucket) InitiateMultipartUpload(key string, XAmzMeta map[string]string) (MultipartUpload, error) {
+	return b.initiateMultipartUpload(context.Background(), key, XAmzMeta)
+}
+
+func (b *Bucket) initiateMultipartUpload(ctx context.Context, key string, XAmzMeta map[string]string) (MultipartUpload, error) {
 	var mu MultipartUpload
 	var params = make(map[string][]string)
 	params["formatter"] = []string{"json"}
@@ -206,6 +527,7 @@ func (b *Bucket) InitiateMultipartUpload(key string, XAmzMeta map[string]string)
 		Path:    fmt.Sprintf("/%s", key),
 		Params:  params,
 		Headers: headers,
+		Context: ctx,
 	}
 	_, body, err := b.c.Query(req)
 	defer body.Close()
@@ -223,7 +545,19 @@ func (b *Bucket) InitiateMultipartUpload(key string, XAmzMeta map[string]string)
 }
 
 // UploadPart 上传分片
-func (b *Bucket) UploadPart(key string, uploadID string, partNumber int, data io.Reader) (Part, error) {
+func (b *Bucket) UploadPart(key string, uploadID string, partNumber int, data io.Reader, opts ...Option) (Part, error) {
+	o := newOptions(opts)
+	length, err := GetReaderLen(data)
+	if err != nil || !isBuffered(data) {
+		length = -1
+	}
+	tracker := newProgressTracker(o.progress, key, length)
+	tracker.started()
+	p, err := b.uploadPart(withProgress(o.context(context.Background()), tracker), key, uploadID, partNumber, data)
+	return p, tracker.finish(err)
+}
+
+func (b *Bucket) uploadPart(ctx context.Context, key string, uploadID string, partNumber int, data io.Reader) (Part, error) {
 	var p Part
 	p.PartNumber = partNumber
 	var params = make(map[string][]string)
@@ -231,30 +565,35 @@ func (b *Bucket) UploadPart(key string, uploadID string, partNumber int, data io
 	params["partNumber"] = []string{fmt.Sprint(partNumber)}
 	params["uploadId"] = []string{uploadID}
 	var headers = make(http.Header)
+	if !isBuffered(data) {
+		// 长度未知的分片读入内存
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, data); err != nil {
+			return p, err
+		}
+		data = &buf
+	}
 	length, err := GetReaderLen(data)
 	if err != nil {
 		return p, err
 	}
 	headers.Set("Content-Length", fmt.Sprint(length))
-	putData, md5, fd, err := calcMD5(data, length)
-	if fd != nil {
-		defer func() {
-			fd.Close()
-			os.Remove(fd.Name())
-		}()
-	}
+	putData, md5, err := calcMD5(data, length)
 	if err != nil {
 		return p, err
 	}
 	headers.Set("Content-MD5", md5)
 	req := &client.Request{
-		Method:  "PUT",
-		Bucket:  b.Name,
-		Path:    fmt.Sprintf("/%s", key),
-		Params:  params,
-		Headers: headers,
-		Body:    putData,
-	}
+		Method:       "PUT",
+		Bucket:       b.Name,
+		Path:         fmt.Sprintf("/%s", key),
+		Params:       params,
+		Headers:      headers,
+		Body:         putData,
+		Context:      ctx,
+		SendProgress: progressFromContext(ctx).countFunc(),
+	}
+	_, req.WriteLimiter = limitersFromContext(ctx)
 	rspHeaders, body, err := b.c.Query(req)
 	defer body.Close()
 	if err != nil {
@@ -262,11 +601,16 @@ func (b *Bucket) UploadPart(key string, uploadID string, partNumber int, data io
 	}
 	p.Size = int(length)
 	p.ETag = rspHeaders.Get("Etag")
+	progressFromContext(ctx).partCompleted(partNumber)
 	return p, nil
 }
 
 // CompleteMultipartUpload 完成分片上传
 func (b *Bucket) CompleteMultipartUpload(key, uploadID string, parts []Part) error {
+	return b.completeMultipartUpload(context.Background(), key, uploadID, parts)
+}
+
+func (b *Bucket) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error {
 	var params = make(map[string][]string)
 	params["formatter"] = []string{"json"}
 	params["uploadId"] = []string{uploadID}
@@ -275,11 +619,31 @@ func (b *Bucket) CompleteMultipartUpload(key, uploadID string, parts []Part) err
 		return err
 	}
 	req := &client.Request{
-		Method: "POST",
+		Method:  "POST",
+		Bucket:  b.Name,
+		Path:    fmt.Sprintf("/%s", key),
+		Params:  params,
+		Body:    bytes.NewBuffer(bts),
+		Context: ctx,
+	}
+	_, body, err := b.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// AbortMultipartUpload 取消分片上传
+func (b *Bucket) AbortMultipartUpload(key, uploadID string) error {
+	var params = make(map[string][]string)
+	params["formatter"] = []string{"json"}
+	params["uploadId"] = []string{uploadID}
+	req := &client.Request{
+		Method: "DELETE",
 		Bucket: b.Name,
 		Path:   fmt.Sprintf("/%s", key),
 		Params: params,
-		Body:   bytes.NewBuffer(bts),
 	}
 	_, body, err := b.c.Query(req)
 	defer body.Close()
@@ -0,0 +1,93 @@
+package scs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// checkpointInterval 每修改多少次状态写入一次断点文件
+const checkpointInterval = 100
+
+// checkpoint 以json保存在文件中的断点状态，用于中断后再次运行时跳过已完成的工作。
+// state为指向map等可json序列化的值的指针，并发访问需通过view和update
+type checkpoint struct {
+	mu       sync.Mutex
+	file     string
+	readOnly bool
+	state    interface{}
+	changes  int
+	err      error
+}
+
+// loadCheckpoint 从file读取状态到state，file为空或不存在时保持state不变，readOnly为true时不写入文件
+func loadCheckpoint(file string, readOnly bool, state interface{}) (*checkpoint, error) {
+	c := &checkpoint{file: file, readOnly: readOnly, state: state}
+	if file == "" {
+		return c, nil
+	}
+	bts, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bts, state); err != nil {
+		return nil, fmt.Errorf("bad checkpoint file %s: %v", file, err)
+	}
+	return c, nil
+}
+
+// view 持锁读取状态
+func (c *checkpoint) view(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn()
+}
+
+// update 持锁修改状态，每checkpointInterval次修改写入一次文件
+func (c *checkpoint) update(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn()
+	if c.changes++; c.changes%checkpointInterval == 0 {
+		c.write()
+	}
+}
+
+// save 写入文件，返回本次或之前写入失败的错误
+func (c *checkpoint) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.write()
+	return c.err
+}
+
+// remove 全部完成后删除文件
+func (c *checkpoint) remove() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == "" || c.readOnly {
+		return c.err
+	}
+	if err := os.Remove(c.file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return c.err
+}
+
+func (c *checkpoint) write() {
+	if c.file == "" || c.readOnly {
+		return
+	}
+	bts, err := json.Marshal(c.state)
+	if err == nil {
+		err = ioutil.WriteFile(c.file, bts, 0644)
+	}
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+}
@@ -0,0 +1,136 @@
+package scs
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// CodecGzip gzip压缩
+const CodecGzip = "gzip"
+
+// 压缩object的user meta
+const (
+	metaCodec        = "x-amz-meta-scs-codec"
+	metaOriginalSize = "x-amz-meta-scs-original-size"
+)
+
+// Codec 压缩算法，Name同时作为Content-Encoding的值
+type Codec interface {
+	Name() string
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{CodecGzip: gzipCodec{}}
+)
+
+// RegisterCodec 注册压缩算法，如zstd，同名的算法会被替换
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[c.Name()] = c
+}
+
+func getCodec(name string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+	return c, nil
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string {
+	return CodecGzip
+}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// compressMeta 返回附加压缩信息后的meta，原始大小在已知时记录
+func compressMeta(c Codec, XAmzMeta map[string]string, size int64) map[string]string {
+	meta := make(map[string]string)
+	for k, v := range XAmzMeta {
+		meta[k] = v
+	}
+	meta[metaCodec] = c.Name()
+	meta["Content-Encoding"] = c.Name()
+	if size >= 0 {
+		meta[metaOriginalSize] = fmt.Sprint(size)
+	}
+	return meta
+}
+
+// compressReader 边读边压缩，返回的reader读到错误或不再使用时需Close
+func compressReader(c Codec, r io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		zw, err := c.NewWriter(pw)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(zw, r); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(zw.Close())
+	}()
+	return pr
+}
+
+// ErrObjectCompressed object通过WithCompression压缩上传，不能按原始偏移随机读取
+var ErrObjectCompressed = errors.New("object is compressed, read it with Get")
+
+// compression 返回object meta中的压缩算法和原始大小，没有压缩时codec为空，原始大小未知时为-1
+func compression(m ObjectMeta) (codec string, size int64) {
+	h := metaHeader(m.XAmzMeta)
+	codec = h.Get(metaCodec)
+	size, err := strconv.ParseInt(h.Get(metaOriginalSize), 10, 64)
+	if codec == "" || err != nil {
+		size = -1
+	}
+	return codec, size
+}
+
+// decompressBody 根据object meta中的压缩信息解压，没有压缩信息时原样返回
+func decompressBody(header http.Header, body io.ReadCloser) (io.ReadCloser, error) {
+	name := header.Get(metaCodec)
+	if name == "" {
+		return body, nil
+	}
+	c, err := getCodec(name)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := c.NewReader(body)
+	if err != nil {
+		return nil, err
+	}
+	return &decompressReadCloser{ReadCloser: zr, body: body}, nil
+}
+
+type decompressReadCloser struct {
+	io.ReadCloser
+	body io.ReadCloser
+}
+
+func (d *decompressReadCloser) Close() error {
+	d.ReadCloser.Close()
+	return d.body.Close()
+}
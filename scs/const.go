@@ -13,7 +13,12 @@ const ACLPublicReadWrite = "public-read-write"
 const ACLAuthenticatedRead = "authenticated-read"
 
 //TempFilePrefix 上传临时文件前缀
+//
+// Deprecated: 上传已不再使用临时文件
 const TempFilePrefix = "scs-temp-"
 
-//MD5Threshold Memory footprint threshold for each MD5 computation (16MB is the default), in byte. When the data is more than that, temp file is used.
+//MD5Threshold Memory footprint threshold for each MD5 computation (16MB is the default), in byte. When a non-seekable body is more than that, multipart upload is used.
 const MD5Threshold = 16 * 1024 * 1024
+
+//PartSize Put切换为分片上传时每个分片的大小
+const PartSize = MD5Threshold
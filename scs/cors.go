@@ -0,0 +1,200 @@
+package scs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// CORSRule bucket跨域规则
+type CORSRule struct {
+	ID string `xml:"ID,omitempty"`
+	// AllowedOrigins 允许的Origin，可以包含一个*通配符
+	AllowedOrigins []string `xml:"AllowedOrigin"`
+	// AllowedMethods 允许的方法，GET、PUT、POST、DELETE、HEAD
+	AllowedMethods []string `xml:"AllowedMethod"`
+	// AllowedHeaders 预检请求中允许的请求头，可以包含一个*通配符
+	AllowedHeaders []string `xml:"AllowedHeader,omitempty"`
+	// ExposeHeaders 允许浏览器读取的响应头
+	ExposeHeaders []string `xml:"ExposeHeader,omitempty"`
+	// MaxAgeSeconds 预检结果的缓存时间，0表示不设置
+	MaxAgeSeconds int `xml:"MaxAgeSeconds,omitempty"`
+}
+
+// corsConfiguration 跨域配置的xml格式
+type corsConfiguration struct {
+	XMLName xml.Name   `xml:"CORSConfiguration"`
+	Rules   []CORSRule `xml:"CORSRule"`
+}
+
+// GetBucketCORS 获取bucket跨域规则，没有设置时返回空，bucket不存在等其他错误照常返回
+func (s *SCS) GetBucketCORS(name string) ([]CORSRule, error) {
+	var params = make(map[string][]string)
+	params["cors"] = []string{""}
+	req := &client.Request{
+		Method: "GET",
+		Bucket: name,
+		Path:   "/",
+		Params: params,
+	}
+	_, rc, err := s.c.Query(req)
+	defer rc.Close()
+	if err != nil {
+		if isErrorCode(err, "NoSuchCORSConfiguration") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	bts, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	var conf corsConfiguration
+	if err := xml.Unmarshal(bts, &conf); err != nil {
+		return nil, err
+	}
+	return conf.Rules, nil
+}
+
+// PutBucketCORS 设置bucket跨域规则，覆盖已有规则
+func (s *SCS) PutBucketCORS(name string, rules []CORSRule) error {
+	data, err := xml.Marshal(corsConfiguration{Rules: rules})
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(data)
+	var params = make(map[string][]string)
+	params["cors"] = []string{""}
+	var headers = make(http.Header)
+	headers.Set("Content-Type", "application/xml")
+	headers.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	headers.Set("Content-Length", strconv.Itoa(len(data)))
+	req := &client.Request{
+		Method:  "PUT",
+		Bucket:  name,
+		Path:    "/",
+		Params:  params,
+		Headers: headers,
+		Body:    bytes.NewReader(data),
+	}
+	_, body, err := s.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteBucketCORS 删除bucket跨域规则
+func (s *SCS) DeleteBucketCORS(name string) error {
+	var params = make(map[string][]string)
+	params["cors"] = []string{""}
+	req := &client.Request{
+		Method: "DELETE",
+		Bucket: name,
+		Path:   "/",
+		Params: params,
+	}
+	_, body, err := s.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// CORSResult EvaluateCORS的结果
+type CORSResult struct {
+	// Allowed 请求是否被允许
+	Allowed bool
+	// Rule 第一个匹配的规则，不允许时为nil
+	Rule *CORSRule
+	// Headers 允许时服务端应返回的Access-Control-*响应头
+	Headers http.Header
+}
+
+// EvaluateCORS 在本地按rules判断跨域请求是否被允许，规则按顺序匹配第一个。
+// 预检请求(OPTIONS)按Access-Control-Request-Method和Access-Control-Request-Headers判断，其他请求按请求方法判断
+func EvaluateCORS(rules []CORSRule, r *http.Request) CORSResult {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return CORSResult{}
+	}
+	method := r.Method
+	var reqHeaders []string
+	preflight := r.Method == "OPTIONS"
+	if preflight {
+		method = r.Header.Get("Access-Control-Request-Method")
+		for _, v := range r.Header.Values("Access-Control-Request-Headers") {
+			for _, h := range strings.Split(v, ",") {
+				if h = strings.TrimSpace(h); h != "" {
+					reqHeaders = append(reqHeaders, h)
+				}
+			}
+		}
+	}
+	for i := range rules {
+		rule := &rules[i]
+		allowOrigin := matchCORS(rule.AllowedOrigins, origin, false)
+		if allowOrigin == "" || matchCORS(rule.AllowedMethods, method, false) == "" {
+			continue
+		}
+		allowed := true
+		for _, h := range reqHeaders {
+			if matchCORS(rule.AllowedHeaders, h, true) == "" {
+				allowed = false
+				break
+			}
+		}
+		if !allowed {
+			continue
+		}
+		headers := make(http.Header)
+		if allowOrigin == "*" {
+			headers.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			headers.Set("Access-Control-Allow-Origin", origin)
+			headers.Set("Vary", "Origin")
+		}
+		headers.Set("Access-Control-Allow-Methods", strings.Join(rule.AllowedMethods, ", "))
+		if len(reqHeaders) > 0 {
+			headers.Set("Access-Control-Allow-Headers", strings.Join(reqHeaders, ", "))
+		}
+		if len(rule.ExposeHeaders) > 0 {
+			headers.Set("Access-Control-Expose-Headers", strings.Join(rule.ExposeHeaders, ", "))
+		}
+		if preflight && rule.MaxAgeSeconds > 0 {
+			headers.Set("Access-Control-Max-Age", strconv.Itoa(rule.MaxAgeSeconds))
+		}
+		return CORSResult{Allowed: true, Rule: rule, Headers: headers}
+	}
+	return CORSResult{}
+}
+
+// matchCORS 返回patterns中第一个匹配s的规则，没有匹配时返回空，规则中可以有一个*通配符
+func matchCORS(patterns []string, s string, fold bool) string {
+	if fold {
+		s = strings.ToLower(s)
+	}
+	for _, p := range patterns {
+		q := p
+		if fold {
+			q = strings.ToLower(q)
+		}
+		if i := strings.Index(q, "*"); i >= 0 {
+			if len(s) >= len(q)-1 && strings.HasPrefix(s, q[:i]) && strings.HasSuffix(s, q[i+1:]) {
+				return p
+			}
+		} else if q == s {
+			return p
+		}
+	}
+	return ""
+}
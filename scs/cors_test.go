@@ -0,0 +1,150 @@
+package scs
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEvaluateCORS(t *testing.T) {
+	rules := []CORSRule{
+		{
+			AllowedOrigins: []string{"https://*.example.com"},
+			AllowedMethods: []string{"GET", "PUT"},
+			AllowedHeaders: []string{"Content-Type", "x-amz-meta-*"},
+			ExposeHeaders:  []string{"ETag"},
+			MaxAgeSeconds:  600,
+		},
+		{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET"},
+		},
+	}
+	tests := []struct {
+		name    string
+		method  string
+		header  map[string]string
+		allowed bool
+		rule    int
+		want    map[string]string
+	}{
+		{name: "no origin", method: "GET"},
+		{
+			name:    "wildcard subdomain",
+			method:  "GET",
+			header:  map[string]string{"Origin": "https://www.example.com"},
+			allowed: true,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":   "https://www.example.com",
+				"Vary":                          "Origin",
+				"Access-Control-Allow-Methods":  "GET, PUT",
+				"Access-Control-Expose-Headers": "ETag",
+				"Access-Control-Max-Age":        "",
+			},
+		},
+		{
+			name:    "falls through to any origin",
+			method:  "GET",
+			header:  map[string]string{"Origin": "http://other.org"},
+			allowed: true,
+			rule:    1,
+			want:    map[string]string{"Access-Control-Allow-Origin": "*", "Vary": "", "Access-Control-Allow-Methods": "GET"},
+		},
+		{
+			name:   "method not allowed by any rule",
+			method: "DELETE",
+			header: map[string]string{"Origin": "https://www.example.com"},
+		},
+		{
+			name:   "origin scheme must match",
+			method: "PUT",
+			header: map[string]string{"Origin": "http://www.example.com"},
+		},
+		{
+			// *可以匹配空串
+			name:    "wildcard matches empty",
+			method:  "PUT",
+			header:  map[string]string{"Origin": "https://.example.com"},
+			allowed: true,
+		},
+		{
+			name:   "preflight",
+			method: "OPTIONS",
+			header: map[string]string{
+				"Origin":                         "https://a.example.com",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "content-type, X-Amz-Meta-Owner",
+			},
+			allowed: true,
+			want: map[string]string{
+				"Access-Control-Allow-Headers": "content-type, X-Amz-Meta-Owner",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "preflight header not allowed",
+			method: "OPTIONS",
+			header: map[string]string{
+				"Origin":                         "https://a.example.com",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "Authorization",
+			},
+		},
+		{
+			name:   "preflight without request method",
+			method: "OPTIONS",
+			header: map[string]string{"Origin": "https://a.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest(tt.method, "http://bucket.example.com/key", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			res := EvaluateCORS(rules, r)
+			if res.Allowed != tt.allowed {
+				t.Fatalf("allowed %v, want %v", res.Allowed, tt.allowed)
+			}
+			if !tt.allowed {
+				if res.Rule != nil || res.Headers != nil {
+					t.Fatalf("denied request has rule %v headers %v", res.Rule, res.Headers)
+				}
+				return
+			}
+			if res.Rule != &rules[tt.rule] {
+				t.Fatalf("matched rule %v, want %d", res.Rule, tt.rule)
+			}
+			for k, v := range tt.want {
+				if got := res.Headers.Get(k); got != v {
+					t.Errorf("%s = %q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchCORS(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		s        string
+		fold     bool
+		want     string
+	}{
+		{[]string{"GET"}, "GET", false, "GET"},
+		{[]string{"GET"}, "get", false, ""},
+		{[]string{"x-amz-*"}, "X-Amz-Date", true, "x-amz-*"},
+		{[]string{"x-amz-*"}, "X-Amz-Date", false, ""},
+		{[]string{"a*a"}, "a", false, ""},
+		{[]string{"a*a"}, "aa", false, "a*a"},
+		{[]string{"*.com", "*"}, "example.org", false, "*"},
+		{nil, "GET", false, ""},
+	}
+	for _, tt := range tests {
+		if got := matchCORS(tt.patterns, tt.s, tt.fold); got != tt.want {
+			t.Errorf("matchCORS(%q, %q, %v) = %q, want %q", tt.patterns, tt.s, tt.fold, got, tt.want)
+		}
+	}
+}
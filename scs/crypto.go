@@ -0,0 +1,496 @@
+package scs
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// EncryptChunkSize 加密object每个明文分块的大小，每个分块单独以AES-256-GCM加密，支持按分块Range读取
+const EncryptChunkSize = 64 * 1024
+
+// 加密object的user meta
+const (
+	metaEncAlgorithm = "x-amz-meta-scs-enc-alg"
+	metaEncKey       = "x-amz-meta-scs-enc-key"
+	metaEncIV        = "x-amz-meta-scs-enc-iv"
+	metaEncChunkSize = "x-amz-meta-scs-enc-chunk"
+)
+
+const encAlgorithm = "AES256-GCM-CHUNKED"
+
+// ErrNotEncrypted object没有客户端加密的meta
+var ErrNotEncrypted = errors.New("object is not client-side encrypted")
+
+// KeyWrapper 使用主密钥加密和解密每个object的数据密钥
+type KeyWrapper interface {
+	WrapKey(key []byte) ([]byte, error)
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+// aesKeyWrapper 以AES-GCM加密数据密钥，输出为nonce+密文
+type aesKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewAESKeyWrapper 返回使用AES-GCM主密钥的KeyWrapper，masterKey长度为16、24或32字节
+func NewAESKeyWrapper(masterKey []byte) (KeyWrapper, error) {
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesKeyWrapper{aead: aead}, nil
+}
+
+func (w *aesKeyWrapper) WrapKey(key []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return w.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (w *aesKeyWrapper) UnwrapKey(wrapped []byte) ([]byte, error) {
+	if len(wrapped) < w.aead.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	n := w.aead.NonceSize()
+	return w.aead.Open(nil, wrapped[:n], wrapped[n:], nil)
+}
+
+// EncryptedBucket 在Bucket上做客户端信封加密，每个object使用独立的AES-256-GCM数据密钥，
+// 数据密钥经KeyWrapper加密后和IV一起保存在x-amz-meta-*中
+type EncryptedBucket struct {
+	b  *Bucket
+	kw KeyWrapper
+}
+
+// NewEncryptedBucket 返回加密的bucket
+func NewEncryptedBucket(b *Bucket, kw KeyWrapper) *EncryptedBucket {
+	return &EncryptedBucket{b: b, kw: kw}
+}
+
+// Put 加密后上传object
+func (e *EncryptedBucket) Put(key string, XAmzMeta map[string]string, data io.Reader) error {
+	aead, iv, meta, err := e.newKey(XAmzMeta)
+	if err != nil {
+		return err
+	}
+	return e.b.putStream(context.Background(), key, meta, newEncryptReader(data, aead, iv))
+}
+
+// NewWriter 返回加密的object写入器，密文通过Bucket.NewWriter分片上传
+func (e *EncryptedBucket) NewWriter(ctx context.Context, key string, opts *WriterOptions) (*EncryptWriter, error) {
+	var o WriterOptions
+	if opts != nil {
+		o = *opts
+	}
+	aead, iv, meta, err := e.newKey(o.XAmzMeta)
+	if err != nil {
+		return nil, err
+	}
+	o.XAmzMeta = meta
+	return &EncryptWriter{
+		w:    e.b.NewWriter(ctx, key, &o),
+		aead: aead,
+		iv:   iv,
+		buf:  make([]byte, 0, EncryptChunkSize),
+	}, nil
+}
+
+// Get 获取并解密object，rg为明文的字节范围，格式同Bucket.Get
+func (e *EncryptedBucket) Get(key string, rg string) (io.ReadCloser, error) {
+	if rg == "" {
+		header, body, err := e.b.getObject(context.Background(), key, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		aead, iv, chunk, err := e.openKey(header.Get)
+		if err != nil {
+			body.Close()
+			return nil, err
+		}
+		return &readCloser{Reader: newDecryptReader(body, aead, iv, chunk, 0, -1), Closer: body}, nil
+	}
+	meta, err := e.b.stat(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	aead, iv, chunk, err := e.openKey(func(k string) string {
+		return metaHeader(meta.XAmzMeta).Get(k)
+	})
+	if err != nil {
+		return nil, err
+	}
+	er, err := encryptedRange(rg, meta.ContentLength, chunk, aead.Overhead())
+	if err != nil {
+		return nil, err
+	}
+	var headers = make(http.Header)
+	if meta.ETag != "" {
+		headers.Set("If-Match", meta.ETag)
+	}
+	_, body, err := e.b.getObject(context.Background(), key, fmt.Sprintf("%d-%d", er.cipherStart, er.cipherEnd), headers)
+	if err != nil {
+		return nil, err
+	}
+	r, err := er.reader(body, aead, iv, chunk)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	return &readCloser{Reader: r, Closer: body}, nil
+}
+
+// encRange 明文范围对应的分块和密文范围，均为闭区间
+type encRange struct {
+	start, end             int64
+	first, last            int64
+	cipherStart, cipherEnd int64
+}
+
+// encryptedRange 将明文范围rg转换为需要读取的密文范围，cipherSize为整个object的密文长度
+func encryptedRange(rg string, cipherSize int64, chunk, overhead int) (encRange, error) {
+	var er encRange
+	if cipherSize < 0 {
+		return er, errors.New("unknown length of encrypted object")
+	}
+	cipherChunk := int64(chunk + overhead)
+	chunks := (cipherSize + cipherChunk - 1) / cipherChunk
+	size := cipherSize - chunks*int64(overhead)
+	start, end, err := parseRange(rg, size)
+	if err != nil {
+		return er, err
+	}
+	er.start, er.end = start, end
+	er.first, er.last = start/int64(chunk), chunks-1
+	er.cipherStart = er.first * cipherChunk
+	er.cipherEnd = (end/int64(chunk)+1)*cipherChunk - 1
+	if er.cipherEnd > cipherSize-1 {
+		er.cipherEnd = cipherSize - 1
+	}
+	return er, nil
+}
+
+// reader 返回从密文范围body中解密出明文范围的reader
+func (er encRange) reader(body io.Reader, aead cipher.AEAD, iv []byte, chunk int) (io.Reader, error) {
+	r := newDecryptReader(body, aead, iv, chunk, uint64(er.first), er.last)
+	if _, err := io.CopyN(ioutil.Discard, r, er.start-er.first*int64(chunk)); err != nil {
+		return nil, err
+	}
+	return io.LimitReader(r, er.end-er.start+1), nil
+}
+
+// newKey 生成数据密钥，返回加密器、IV和附加加密信息后的meta
+func (e *EncryptedBucket) newKey(XAmzMeta map[string]string) (cipher.AEAD, []byte, map[string]string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+	wrapped, err := e.kw.WrapKey(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	meta := make(map[string]string)
+	for k, v := range XAmzMeta {
+		meta[k] = v
+	}
+	meta[metaEncAlgorithm] = encAlgorithm
+	meta[metaEncKey] = base64.StdEncoding.EncodeToString(wrapped)
+	meta[metaEncIV] = base64.StdEncoding.EncodeToString(iv)
+	meta[metaEncChunkSize] = strconv.Itoa(EncryptChunkSize)
+	return aead, iv, meta, nil
+}
+
+// openKey 从object meta中解出数据密钥
+func (e *EncryptedBucket) openKey(get func(string) string) (cipher.AEAD, []byte, int, error) {
+	if get(metaEncAlgorithm) != encAlgorithm {
+		return nil, nil, 0, ErrNotEncrypted
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(get(metaEncKey))
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("bad encryption key meta: %v", err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(get(metaEncIV))
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("bad encryption iv meta: %v", err)
+	}
+	chunk, err := strconv.Atoi(get(metaEncChunkSize))
+	if err != nil || chunk <= 0 {
+		return nil, nil, 0, fmt.Errorf("bad encryption chunk meta %q", get(metaEncChunkSize))
+	}
+	key, err := e.kw.UnwrapKey(wrapped)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if len(iv) != aead.NonceSize() {
+		return nil, nil, 0, errors.New("bad encryption iv length")
+	}
+	return aead, iv, chunk, nil
+}
+
+// EncryptWriter 加密的object写入器
+type EncryptWriter struct {
+	w     *Writer
+	aead  cipher.AEAD
+	iv    []byte
+	index uint64
+	buf   []byte
+}
+
+var _ io.WriteCloser = (*EncryptWriter)(nil)
+
+// Write 缓冲明文，满一个分块后加密写入
+func (w *EncryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// 保留一个完整分块，直到确认后面还有数据，最后一个分块在Close时以final标记加密
+		if len(w.buf) == EncryptChunkSize {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := EncryptChunkSize - len(w.buf)
+		if n > len(p) {
+			n = len(p)
+		}
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close 加密最后一个分块并完成上传
+func (w *EncryptWriter) Close() error {
+	if err := w.seal(true); err != nil {
+		w.w.CloseWithError(err)
+		return err
+	}
+	return w.w.Close()
+}
+
+// CloseWithError 放弃上传
+func (w *EncryptWriter) CloseWithError(err error) error {
+	return w.w.CloseWithError(err)
+}
+
+func (w *EncryptWriter) seal(final bool) error {
+	out := w.aead.Seal(nil, chunkNonce(w.iv, w.index), w.buf, chunkAAD(final))
+	w.index++
+	w.buf = w.buf[:0]
+	_, err := w.w.Write(out)
+	return err
+}
+
+// encryptReader 将明文流转换为分块密文流
+type encryptReader struct {
+	r     *bufio.Reader
+	aead  cipher.AEAD
+	iv    []byte
+	index uint64
+	plain []byte
+	out   []byte
+	done  bool
+}
+
+func newEncryptReader(r io.Reader, aead cipher.AEAD, iv []byte) *encryptReader {
+	return &encryptReader{
+		r:     bufio.NewReaderSize(r, EncryptChunkSize),
+		aead:  aead,
+		iv:    iv,
+		plain: make([]byte, EncryptChunkSize),
+	}
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(e.r, e.plain)
+		final := false
+		switch err {
+		case nil:
+			if _, perr := e.r.Peek(1); perr == io.EOF {
+				final = true
+			} else if perr != nil {
+				return 0, perr
+			}
+		case io.EOF, io.ErrUnexpectedEOF:
+			final = true
+		default:
+			return 0, err
+		}
+		e.out = e.aead.Seal(e.out[:0], chunkNonce(e.iv, e.index), e.plain[:n], chunkAAD(final))
+		e.index++
+		e.done = final
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+// decryptReader 将分块密文流解密为明文，last为最后一个分块的序号，未知时为-1
+type decryptReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	iv     []byte
+	index  uint64
+	last   int64
+	cipher []byte
+	out    []byte
+	done   bool
+}
+
+func newDecryptReader(r io.Reader, aead cipher.AEAD, iv []byte, chunk int, index uint64, last int64) *decryptReader {
+	size := chunk + aead.Overhead()
+	return &decryptReader{
+		r:      bufio.NewReaderSize(r, size),
+		aead:   aead,
+		iv:     iv,
+		index:  index,
+		last:   last,
+		cipher: make([]byte, size),
+	}
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(d.r, d.cipher)
+		eof := false
+		switch err {
+		case nil:
+			if _, perr := d.r.Peek(1); perr == io.EOF {
+				eof = true
+			} else if perr != nil {
+				return 0, perr
+			}
+		case io.EOF, io.ErrUnexpectedEOF:
+			eof = true
+		default:
+			return 0, err
+		}
+		final := eof
+		if d.last >= 0 {
+			final = int64(d.index) == d.last
+		}
+		plain, err := d.aead.Open(d.out[:0], chunkNonce(d.iv, d.index), d.cipher[:n], chunkAAD(final))
+		if err != nil {
+			return 0, fmt.Errorf("decrypt chunk %d: %v", d.index, err)
+		}
+		d.out = plain
+		d.index++
+		d.done = eof
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce 第index个分块的nonce，为IV与分块序号异或
+func chunkNonce(iv []byte, index uint64) []byte {
+	nonce := make([]byte, len(iv))
+	copy(nonce, iv)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(index >> (8 * uint(i)))
+	}
+	return nonce
+}
+
+// chunkAAD 附加数据标记最后一个分块，防止密文被截断
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// metaHeader 将XAmzMeta转换为http.Header以便忽略大小写查找
+func metaHeader(meta map[string]string) http.Header {
+	h := make(http.Header)
+	for k, v := range meta {
+		h.Set(k, v)
+	}
+	return h
+}
+
+// parseRange 解析"a-b"、"a-"或"-n"形式的范围，返回闭区间
+func parseRange(rg string, size int64) (int64, int64, error) {
+	i := strings.Index(rg, "-")
+	if i < 0 {
+		return 0, 0, fmt.Errorf("bad range %q", rg)
+	}
+	startStr, endStr := strings.TrimSpace(rg[:i]), strings.TrimSpace(rg[i+1:])
+	var start, end int64
+	var err error
+	if startStr == "" {
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("bad range %q", rg)
+		}
+		if n > size {
+			n = size
+		}
+		start, end = size-n, size-1
+	} else {
+		if start, err = strconv.ParseInt(startStr, 10, 64); err != nil || start < 0 {
+			return 0, 0, fmt.Errorf("bad range %q", rg)
+		}
+		end = size - 1
+		if endStr != "" {
+			if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+				return 0, 0, fmt.Errorf("bad range %q", rg)
+			}
+			if end > size-1 {
+				end = size - 1
+			}
+		}
+	}
+	if start >= size || start > end {
+		return 0, 0, fmt.Errorf("range %q not satisfiable for size %d", rg, size)
+	}
+	return start, end, nil
+}
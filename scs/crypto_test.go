@@ -0,0 +1,169 @@
+package scs
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+func testGCM(t *testing.T) (cipher.AEAD, []byte) {
+	t.Helper()
+	aead, err := newGCM(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aead, bytes.Repeat([]byte{3}, aead.NonceSize())
+}
+
+func testPlain(n int) []byte {
+	p := make([]byte, n)
+	for i := range p {
+		p[i] = byte(i * 31)
+	}
+	return p
+}
+
+func encryptAll(t *testing.T, aead cipher.AEAD, iv, plain []byte) []byte {
+	t.Helper()
+	ct, err := ioutil.ReadAll(newEncryptReader(bytes.NewReader(plain), aead, iv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ct
+}
+
+func decryptAll(aead cipher.AEAD, iv, ct []byte) ([]byte, error) {
+	return ioutil.ReadAll(newDecryptReader(bytes.NewReader(ct), aead, iv, EncryptChunkSize, 0, -1))
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	aead, iv := testGCM(t)
+	const chunk = EncryptChunkSize
+	for _, n := range []int{0, 1, chunk - 1, chunk, chunk + 1, 2 * chunk, 3*chunk + 5} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			plain := testPlain(n)
+			ct := encryptAll(t, aead, iv, plain)
+			chunks := n/chunk + 1
+			if n > 0 && n%chunk == 0 {
+				chunks--
+			}
+			if want := n + chunks*aead.Overhead(); len(ct) != want {
+				t.Fatalf("ciphertext length %d, want %d", len(ct), want)
+			}
+			got, err := decryptAll(aead, iv, ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, plain) {
+				t.Fatalf("round trip mismatch, got %d bytes", len(got))
+			}
+		})
+	}
+}
+
+func TestEncryptedRange(t *testing.T) {
+	aead, iv := testGCM(t)
+	const chunk = EncryptChunkSize
+	plain := testPlain(3*chunk + 100)
+	ct := encryptAll(t, aead, iv, plain)
+	size := int64(len(plain))
+	tests := []struct {
+		rg         string
+		start, end int64
+	}{
+		{"0-0", 0, 0},
+		{"0-", 0, size - 1},
+		{fmt.Sprintf("%d-%d", chunk-1, chunk), chunk - 1, chunk},
+		{fmt.Sprintf("%d-%d", chunk, chunk), chunk, chunk},
+		{fmt.Sprintf("%d-%d", 10, 2*chunk+10), 10, 2*chunk + 10},
+		{fmt.Sprintf("%d-", 3*chunk), 3 * chunk, size - 1},
+		{"-50", size - 50, size - 1},
+		{fmt.Sprintf("%d-%d", size-10, size+1000), size - 10, size - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rg, func(t *testing.T) {
+			er, err := encryptedRange(tt.rg, int64(len(ct)), chunk, aead.Overhead())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if er.start != tt.start || er.end != tt.end {
+				t.Fatalf("range %d-%d, want %d-%d", er.start, er.end, tt.start, tt.end)
+			}
+			r, err := er.reader(bytes.NewReader(ct[er.cipherStart:er.cipherEnd+1]), aead, iv, chunk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, plain[tt.start:tt.end+1]) {
+				t.Fatalf("got %d bytes, want %d", len(got), tt.end-tt.start+1)
+			}
+		})
+	}
+	for _, rg := range []string{fmt.Sprintf("%d-", size), "5-4", "x", "--1"} {
+		if _, err := encryptedRange(rg, int64(len(ct)), chunk, aead.Overhead()); err == nil {
+			t.Errorf("range %q: expected error", rg)
+		}
+	}
+	if _, err := encryptedRange("0-", -1, chunk, aead.Overhead()); err == nil {
+		t.Error("unknown length: expected error")
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	aead, iv := testGCM(t)
+	cipherChunk := EncryptChunkSize + aead.Overhead()
+	ct := encryptAll(t, aead, iv, testPlain(3*EncryptChunkSize+100))
+	modify := func(fn func([]byte) []byte) []byte {
+		return fn(append([]byte(nil), ct...))
+	}
+	tests := []struct {
+		name string
+		ct   []byte
+	}{
+		{"flipped byte", modify(func(b []byte) []byte {
+			b[cipherChunk+10] ^= 1
+			return b
+		})},
+		{"flipped tag", modify(func(b []byte) []byte {
+			b[len(b)-1] ^= 1
+			return b
+		})},
+		{"truncated at chunk boundary", ct[:2*cipherChunk]},
+		{"truncated mid chunk", ct[:2*cipherChunk+100]},
+		{"last chunk dropped", ct[:3*cipherChunk]},
+		{"empty", nil},
+		{"reordered", modify(func(b []byte) []byte {
+			first := append([]byte(nil), b[:cipherChunk]...)
+			copy(b, b[cipherChunk:2*cipherChunk])
+			copy(b[cipherChunk:], first)
+			return b
+		})},
+		{"chunk duplicated", append(append([]byte(nil), ct[:cipherChunk]...), ct...)},
+		{"extra chunk appended", append(append([]byte(nil), ct...), ct[len(ct)-100-aead.Overhead():]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decryptAll(aead, iv, tt.ct); err == nil {
+				t.Fatal("expected decrypt error")
+			}
+		})
+	}
+
+	// 按Range读取时，分块序号由请求的范围决定，其他位置的分块不能通过校验
+	er, err := encryptedRange(fmt.Sprintf("%d-%d", EncryptChunkSize, EncryptChunkSize+10), int64(len(ct)), EncryptChunkSize, aead.Overhead())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := er.reader(bytes.NewReader(ct[:cipherChunk]), aead, iv, EncryptChunkSize)
+	if err == nil {
+		_, err = ioutil.ReadAll(r)
+	}
+	if err == nil {
+		t.Fatal("expected error for chunk from another position")
+	}
+}
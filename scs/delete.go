@@ -0,0 +1,265 @@
+package scs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+const (
+	// DeleteBatchSize 一次批量删除请求最多包含的key数
+	DeleteBatchSize = 1000
+	// deleteConcurrency 不支持批量删除时并发删除的数量
+	deleteConcurrency = 8
+)
+
+// DeleteResult DeleteMany中单个key的删除结果
+type DeleteResult struct {
+	Key string
+	Err error
+}
+
+// featureMultiDelete 批量删除请求，服务端不支持时记录在client上，之后直接并发逐个删除
+const featureMultiDelete = "delete"
+
+// DeleteMany 删除多个key，返回与keys顺序一致的每个key的结果，key不存在视为删除成功。
+// 服务端支持时每DeleteBatchSize个key发送一次批量删除请求，否则并发逐个删除，有key删除失败时error不为nil
+func (b *Bucket) DeleteMany(keys []string) ([]DeleteResult, error) {
+	return b.deleteMany(context.Background(), keys)
+}
+
+// DeletePrefix 删除prefix下的所有object，返回删除成功的数量。prefix不能为空，删除bucket中所有object使用DeleteAll
+func (b *Bucket) DeletePrefix(prefix string) (int, error) {
+	if prefix == "" {
+		return 0, errors.New("delete prefix requires a non-empty prefix, use DeleteAll to empty the bucket")
+	}
+	return b.deletePrefix(context.Background(), prefix, nil)
+}
+
+// DeleteAll 删除bucket中所有object，返回删除成功的数量。为防止误删，confirm必须匹配bucket名称，规则同path.Match
+func (b *Bucket) DeleteAll(confirm string) (int, error) {
+	if err := confirmBucket(confirm, b.Name); err != nil {
+		return 0, err
+	}
+	return b.deletePrefix(context.Background(), "", nil)
+}
+
+// confirmBucket 检查confirm规则是否匹配bucket名称，规则为空或不匹配时返回错误
+func confirmBucket(confirm, name string) error {
+	if confirm == "" {
+		return fmt.Errorf("deleting all objects in bucket %s requires a confirm pattern", name)
+	}
+	matched, err := path.Match(confirm, name)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return fmt.Errorf("bucket %s does not match confirm pattern %q", name, confirm)
+	}
+	return nil
+}
+
+func (b *Bucket) deleteMany(ctx context.Context, keys []string) ([]DeleteResult, error) {
+	results := make([]DeleteResult, len(keys))
+	for i, key := range keys {
+		results[i].Key = key
+	}
+	for start := 0; start < len(results); start += DeleteBatchSize {
+		end := start + DeleteBatchSize
+		if end > len(results) {
+			end = len(results)
+		}
+		if err := b.deleteBatch(ctx, results[start:end]); err != nil {
+			return results, err
+		}
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d keys failed to delete", failed, len(results))
+	}
+	return results, nil
+}
+
+// deletePrefix 分页列出prefix下的object并批量删除，fn不为nil时每批删除后回调
+func (b *Bucket) deletePrefix(ctx context.Context, prefix string, fn func([]DeleteResult)) (int, error) {
+	deleted, failed := 0, 0
+	var firstErr error
+	keys := make([]string, 0, DeleteBatchSize)
+	flush := func() error {
+		results, _ := b.deleteMany(ctx, keys)
+		keys = keys[:0]
+		for _, r := range results {
+			if r.Err == nil {
+				deleted++
+				continue
+			}
+			failed++
+			if firstErr == nil {
+				firstErr = r.Err
+			}
+		}
+		if fn != nil {
+			fn(results)
+		}
+		return ctx.Err()
+	}
+	err := b.walk(ctx, prefix, func(obj Object) error {
+		keys = append(keys, obj.Name)
+		if len(keys) < DeleteBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(keys) > 0 {
+		err = flush()
+	}
+	if err != nil {
+		return deleted, err
+	}
+	if failed > 0 {
+		return deleted, fmt.Errorf("%d keys failed to delete, first error: %v", failed, firstErr)
+	}
+	return deleted, nil
+}
+
+// deleteBatch 删除不超过DeleteBatchSize个key，结果写入results，只在ctx结束时返回error
+func (b *Bucket) deleteBatch(ctx context.Context, results []DeleteResult) error {
+	if !b.c.Unsupported(featureMultiDelete) {
+		err := b.multiDelete(ctx, results)
+		if !isMultiDeleteUnsupported(err) {
+			if err != nil {
+				for i := range results {
+					results[i].Err = err
+				}
+			}
+			return ctx.Err()
+		}
+		b.c.SetUnsupported(featureMultiDelete)
+	}
+	pool := newWorkerPool(ctx, deleteConcurrency)
+	var err error
+	for i := range results {
+		i := i
+		err = pool.submit(func() {
+			err := b.deleteObject(ctx, results[i].Key)
+			if isNotFound(err) && !isNoSuchBucket(err) {
+				err = nil
+			}
+			results[i].Err = err
+		})
+		if err != nil {
+			for ; i < len(results); i++ {
+				results[i].Err = err
+			}
+			break
+		}
+	}
+	pool.wait()
+	return err
+}
+
+// multiDeleteRequest 批量删除请求
+type multiDeleteRequest struct {
+	XMLName xml.Name            `xml:"Delete"`
+	Quiet   bool                `xml:"Quiet"`
+	Objects []multiDeleteObject `xml:"Object"`
+}
+
+type multiDeleteObject struct {
+	Key string `xml:"Key"`
+}
+
+// multiDeleteResult 批量删除结果，Quiet模式下只返回删除失败的key
+type multiDeleteResult struct {
+	Errors []struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
+
+// multiDelete 发送批量删除请求
+func (b *Bucket) multiDelete(ctx context.Context, results []DeleteResult) error {
+	dr := multiDeleteRequest{Quiet: true}
+	for _, r := range results {
+		dr.Objects = append(dr.Objects, multiDeleteObject{Key: r.Key})
+	}
+	data, err := xml.Marshal(dr)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(data)
+	var params = make(map[string][]string)
+	params["delete"] = []string{""}
+	req := &client.Request{
+		Method: "POST",
+		Bucket: b.Name,
+		Path:   "/",
+		Params: params,
+		Headers: map[string][]string{
+			"Content-Type":   {"application/xml"},
+			"Content-MD5":    {base64.StdEncoding.EncodeToString(sum[:])},
+			"Content-Length": {strconv.Itoa(len(data))},
+		},
+		Body:    bytes.NewReader(data),
+		Context: ctx,
+	}
+	_, body, err := b.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	bts, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	var res multiDeleteResult
+	if err := xml.Unmarshal(bts, &res); err != nil {
+		return err
+	}
+	errs := make(map[string]error)
+	for _, e := range res.Errors {
+		if e.Code == "NoSuchKey" {
+			continue
+		}
+		errs[e.Key] = errors.New(e.Code + ": " + e.Message)
+	}
+	for i := range results {
+		results[i].Err = errs[results[i].Key]
+	}
+	return nil
+}
+
+// isMultiDeleteUnsupported 判断是否因服务端不支持批量删除而失败，400等其他错误是对这一批请求的拒绝，
+// 作为每个key的错误返回
+func isMultiDeleteUnsupported(err error) bool {
+	e, ok := err.(*client.Error)
+	if !ok {
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return e.ErrorCode == "NotImplemented"
+}
+
+func isNoSuchBucket(err error) bool {
+	e, ok := err.(*client.Error)
+	return ok && e.ErrorCode == "NoSuchBucket"
+}
@@ -0,0 +1,20 @@
+package scs
+
+import "testing"
+
+func TestDeleteAllGuards(t *testing.T) {
+	b := &Bucket{Name: "prod-assets"}
+	if _, err := b.DeletePrefix(""); err == nil {
+		t.Error("DeletePrefix with empty prefix: expected error")
+	}
+	for _, confirm := range []string{"", "test-*", "prod", "[", "*-assets-*"} {
+		if _, err := b.DeleteAll(confirm); err == nil {
+			t.Errorf("DeleteAll(%q): expected error", confirm)
+		}
+	}
+	for _, confirm := range []string{"prod-assets", "prod-*", "*"} {
+		if err := confirmBucket(confirm, b.Name); err != nil {
+			t.Errorf("confirmBucket(%q): %v", confirm, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package scs
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// forceDeleteAttempts bucket删除因非空失败时重新清空的次数
+const forceDeleteAttempts = 3
+
+// ForceDeleteOptions DeleteBucketForce可选参数
+type ForceDeleteOptions struct {
+	// Confirm 必须匹配bucket名称的规则，规则同path.Match，如"test-*"，为空时拒绝删除
+	Confirm string
+	// Progress 每删除一批object或取消一个分片上传后回调
+	Progress func(p ForceDeleteProgress)
+}
+
+// ForceDeleteProgress DeleteBucketForce的进度
+type ForceDeleteProgress struct {
+	// Deleted 已删除的object数
+	Deleted int
+	// Failed 删除失败的object数
+	Failed int
+	// Aborted 已取消的分片上传数
+	Aborted int
+}
+
+// DeleteBucketForce 删除bucket中所有object，取消所有进行中的分片上传，然后删除bucket。
+// 为防止误删，opts.Confirm必须匹配bucket名称
+func (s *SCS) DeleteBucketForce(ctx context.Context, name string, opts *ForceDeleteOptions) error {
+	var o ForceDeleteOptions
+	if opts != nil {
+		o = *opts
+	}
+	if err := confirmBucket(o.Confirm, name); err != nil {
+		return err
+	}
+
+	b := s.Bucket(name)
+	var progress ForceDeleteProgress
+	report := func() {
+		if o.Progress != nil {
+			o.Progress(progress)
+		}
+	}
+	for attempt := 1; ; attempt++ {
+		_, err := b.deletePrefix(ctx, "", func(results []DeleteResult) {
+			for _, r := range results {
+				if r.Err != nil {
+					progress.Failed++
+				} else {
+					progress.Deleted++
+				}
+			}
+			report()
+		})
+		if err != nil {
+			return err
+		}
+		err = b.walkUploads(ctx, "", func(u Upload) error {
+			if err := b.AbortMultipartUpload(u.Key, u.UploadID); err != nil && !isNotFound(err) {
+				return err
+			}
+			progress.Aborted++
+			report()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		err = s.DeleteBucket(name)
+		if e, ok := err.(*client.Error); ok && e.StatusCode == http.StatusConflict && attempt < forceDeleteAttempts {
+			// 列表可能滞后于删除，重新清空后再试
+			continue
+		}
+		return err
+	}
+}
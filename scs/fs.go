@@ -0,0 +1,256 @@
+package scs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FS bucket上的只读文件系统，实现fs.FS、fs.ReadDirFS、fs.StatFS和fs.ReadFileFS。
+// 目录由以"/"为分隔符的列表中的CommonPrefixes得到，文件内容按需通过Range请求读取。
+// 通过WithCompression压缩的object读到的是解压后的内容，打开后只能顺序读取，
+// Stat返回解压后的大小，ReadDir的结果来自列表，大小为压缩后保存的大小。
+type FS struct {
+	b      *Bucket
+	prefix string
+}
+
+var (
+	_ fs.FS         = (*FS)(nil)
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+)
+
+// FS 返回以prefix为根目录的文件系统
+func (b *Bucket) FS(prefix string) *FS {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &FS{b: b, prefix: prefix}
+}
+
+// Open 打开文件或目录
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name != "." {
+		key := f.key(name)
+		meta, err := f.b.stat(context.Background(), key)
+		if err == nil {
+			info := metaInfo(path.Base(name), meta)
+			if codec, _ := compression(meta); codec == "" {
+				return &fsFile{ObjectReader: f.b.openObject(key, meta), info: info}, nil
+			}
+			// 压缩的object不能随机读取，顺序读取解压后的内容
+			rc, err := f.b.Get(key, "")
+			if err != nil {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+			}
+			return &fsStream{ReadCloser: rc, info: info}, nil
+		}
+		if !isNotFound(err) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &fsDir{info: dirInfo(path.Base(name)), entries: entries}, nil
+}
+
+// Stat 获取文件或目录信息
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return dirInfo("."), nil
+	}
+	meta, err := f.b.stat(context.Background(), f.key(name))
+	if err == nil {
+		return metaInfo(path.Base(name), meta), nil
+	}
+	if !isNotFound(err) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	lo, err := f.b.List("/", f.key(name)+"/", "", 1)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if len(lo.Contents) == 0 && len(lo.CommonPrefixes) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return dirInfo(path.Base(name)), nil
+}
+
+// ReadDir 列出目录，结果按文件名排序
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return entries, nil
+}
+
+// ReadFile 读取整个文件
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	rc, err := f.b.Get(f.key(name), "")
+	if err != nil {
+		if isNotFound(err) {
+			err = fs.ErrNotExist
+		}
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func (f *FS) key(name string) string {
+	if name == "." {
+		return f.prefix
+	}
+	return f.prefix + name
+}
+
+func (f *FS) readDir(name string) ([]fs.DirEntry, error) {
+	dir := f.key(name)
+	if name != "." {
+		dir += "/"
+	}
+	entries := make([]fs.DirEntry, 0)
+	err := f.b.ListPages(context.Background(), "/", dir, func(lo ListObject) error {
+		for _, cp := range lo.CommonPrefixes {
+			entries = append(entries, fs.FileInfoToDirEntry(dirInfo(path.Base(cp.Prefix))))
+		}
+		for _, o := range lo.Contents {
+			if o.Name == dir {
+				// 目录占位object
+				continue
+			}
+			entries = append(entries, fs.FileInfoToDirEntry(objectInfo(path.Base(o.Name), o)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if name != "." && len(entries) == 0 {
+		return nil, fs.ErrNotExist
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// fileInfo 实现fs.FileInfo，Sys返回Object或ObjectMeta
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	sys     interface{}
+}
+
+func dirInfo(name string) *fileInfo {
+	return &fileInfo{name: name, dir: true}
+}
+
+func objectInfo(name string, o Object) *fileInfo {
+	return &fileInfo{name: name, size: o.Size, modTime: o.LastModifiedTime(), sys: o}
+}
+
+// metaInfo 文件信息，压缩的object大小为解压后的大小，未知时为0
+func metaInfo(name string, m ObjectMeta) *fileInfo {
+	size := m.ContentLength
+	if codec, original := compression(m); codec != "" {
+		size = original
+	}
+	if size < 0 {
+		size = 0
+	}
+	return &fileInfo{name: name, size: size, modTime: m.LastModifiedTime(), sys: m}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+func (fi *fileInfo) Sys() interface{}   { return fi.sys }
+
+func (fi *fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// fsFile 文件，基于ObjectReader按需读取
+type fsFile struct {
+	*ObjectReader
+	info *fileInfo
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// fsStream 压缩的文件，顺序读取解压后的内容
+type fsStream struct {
+	io.ReadCloser
+	info *fileInfo
+}
+
+func (f *fsStream) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// fsDir 目录，实现fs.ReadDirFile
+type fsDir struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *fsDir) Close() error {
+	return nil
+}
+
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
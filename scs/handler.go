@@ -0,0 +1,238 @@
+package scs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// HandlerOptions NewHandler可选参数
+type HandlerOptions struct {
+	// Prefix URL路径映射为key时添加的前缀
+	Prefix string
+	// ListDirectories 为true时以"/"结尾的路径返回目录列表页面，否则返回404
+	ListDirectories bool
+	// Authorize 访问控制钩子，返回错误时响应403
+	Authorize func(r *http.Request, key string) error
+}
+
+// handler 将URL路径映射为key，转发Range和条件请求，响应体直接来自Bucket.Get
+type handler struct {
+	b    *Bucket
+	opts HandlerOptions
+}
+
+// NewHandler 返回代理bucket中object的http.Handler，通过WithCompression压缩的object不解压，
+// 原样返回并带上Content-Encoding，由客户端解压
+func NewHandler(b *Bucket, opts *HandlerOptions) http.Handler {
+	var o HandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	return &handler{b: b, opts: o}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	upath := r.URL.Path
+	if !strings.HasPrefix(upath, "/") {
+		upath = "/" + upath
+	}
+	isDir := strings.HasSuffix(upath, "/")
+	key := strings.TrimPrefix(path.Clean(upath), "/")
+	if isDir && key != "" {
+		key += "/"
+	}
+	key = h.opts.Prefix + key
+	if h.opts.Authorize != nil {
+		if err := h.opts.Authorize(r, key); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+	if isDir {
+		if !h.opts.ListDirectories {
+			http.NotFound(w, r)
+			return
+		}
+		h.serveDir(w, r, key)
+		return
+	}
+	h.serveObject(w, r, key)
+}
+
+// handlerAttempts Head和Get之间object被修改时最多读取的次数
+const handlerAttempts = 2
+
+func (h *handler) serveObject(w http.ResponseWriter, r *http.Request, key string) {
+	var meta ObjectMeta
+	var header http.Header
+	var body io.ReadCloser
+	var err error
+	var unchanged bool
+	for attempt := 1; ; attempt++ {
+		if meta, err = h.b.head(r.Context(), key); err != nil {
+			h.serveError(w, err)
+			return
+		}
+		unchanged = notModified(r, meta.ETag, meta.LastModifiedTime())
+		if r.Method == "HEAD" || unchanged {
+			break
+		}
+		var headers = make(http.Header)
+		if meta.ETag != "" {
+			headers.Set("If-Match", meta.ETag)
+		}
+		rg := singleRange(r.Header.Get("Range"))
+		if meta.ContentLength == 0 {
+			rg = ""
+		}
+		header, body, err = h.b.getObject(r.Context(), key, rg, headers)
+		e, ok := err.(*client.Error)
+		if !ok || e.StatusCode != http.StatusPreconditionFailed {
+			break
+		}
+		if attempt == handlerAttempts {
+			// 客户端没有发送If-Match，不能返回412
+			err = fmt.Errorf("object %s keeps changing: %v", key, err)
+			break
+		}
+	}
+	if err != nil {
+		if e, ok := err.(*client.Error); ok && e.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", meta.ContentLength))
+		}
+		h.serveError(w, err)
+		return
+	}
+	if meta.ContentType != "" {
+		w.Header().Set("Content-Type", meta.ContentType)
+	}
+	if meta.ETag != "" {
+		w.Header().Set("ETag", meta.ETag)
+	}
+	if meta.LastModified != "" {
+		w.Header().Set("Last-Modified", meta.LastModified)
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	if unchanged {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if r.Method == "HEAD" {
+		if meta.ContentLength >= 0 {
+			w.Header().Set("Content-Length", fmt.Sprint(meta.ContentLength))
+		}
+		if meta.ContentEncoding != "" {
+			w.Header().Set("Content-Encoding", meta.ContentEncoding)
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	defer body.Close()
+	if v := header.Get("Content-Length"); v != "" {
+		w.Header().Set("Content-Length", v)
+	}
+	if v := header.Get("Content-Encoding"); v != "" {
+		w.Header().Set("Content-Encoding", v)
+	}
+	if v := header.Get("Content-Range"); v != "" {
+		w.Header().Set("Content-Range", v)
+		w.WriteHeader(http.StatusPartialContent)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	io.Copy(w, body)
+}
+
+func (h *handler) serveDir(w http.ResponseWriter, r *http.Request, prefix string) {
+	var prefixes []CommonPrefix
+	var objects []Object
+	err := h.b.ListPages(r.Context(), "/", prefix, func(lo ListObject) error {
+		prefixes = append(prefixes, lo.CommonPrefixes...)
+		objects = append(objects, lo.Contents...)
+		return nil
+	})
+	if err != nil {
+		h.serveError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == "HEAD" {
+		return
+	}
+	fmt.Fprintf(w, "<!doctype html>\n<title>%s</title>\n<pre>\n", html.EscapeString(r.URL.Path))
+	for _, cp := range prefixes {
+		name := path.Base(cp.Prefix) + "/"
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", (&url.URL{Path: name}).String(), html.EscapeString(name))
+	}
+	for _, o := range objects {
+		if o.Name == prefix {
+			continue
+		}
+		name := path.Base(o.Name)
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\t%d\t%s\n", (&url.URL{Path: name}).String(), html.EscapeString(name), o.Size, html.EscapeString(o.LastModified))
+	}
+	fmt.Fprintf(w, "</pre>\n")
+}
+
+func (h *handler) serveError(w http.ResponseWriter, err error) {
+	code := http.StatusBadGateway
+	if e, ok := err.(*client.Error); ok {
+		code = e.StatusCode
+	} else if err == ErrObjectNotFound {
+		code = http.StatusNotFound
+	} else if errors.Is(err, context.Canceled) {
+		// 客户端断开，client返回的*url.Error包装了context.Canceled
+		return
+	}
+	http.Error(w, http.StatusText(code), code)
+}
+
+// notModified 根据If-None-Match和If-Modified-Since判断是否返回304
+func notModified(r *http.Request, etag string, modtime time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+		for _, v := range strings.Split(inm, ",") {
+			v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+			if v == "*" || strings.Trim(v, "\"") == strings.Trim(etag, "\"") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modtime.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err == nil && !modtime.Truncate(time.Second).After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// singleRange 将"bytes=a-b"形式的单个Range转换为Get的rg参数，多个Range时忽略
+func singleRange(v string) string {
+	if !strings.HasPrefix(v, "bytes=") {
+		return ""
+	}
+	rg := strings.TrimSpace(strings.TrimPrefix(v, "bytes="))
+	if rg == "" || strings.Contains(rg, ",") || !strings.Contains(rg, "-") {
+		return ""
+	}
+	return rg
+}
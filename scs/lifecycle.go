@@ -0,0 +1,282 @@
+package scs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// LifecycleRule bucket生命周期规则
+type LifecycleRule struct {
+	ID string
+	// Prefix 规则作用的key前缀，为空时作用于整个bucket
+	Prefix string
+	// Disabled 为true时规则不生效
+	Disabled bool
+	// ExpirationDays object最后修改多少天后删除，0表示不按天数删除
+	ExpirationDays int
+	// ExpirationDate 该时间之后删除object，零值表示不按日期删除
+	ExpirationDate time.Time
+	// AbortIncompleteUploadDays 分片上传开始多少天后未完成则取消，0表示不取消
+	AbortIncompleteUploadDays int
+}
+
+// expired 判断最后修改时间为modtime的object在now时是否过期
+func (r LifecycleRule) expired(modtime, now time.Time) bool {
+	if r.ExpirationDays > 0 && !modtime.IsZero() && !now.Before(modtime.AddDate(0, 0, r.ExpirationDays)) {
+		return true
+	}
+	return !r.ExpirationDate.IsZero() && !now.Before(r.ExpirationDate)
+}
+
+// lifecycleConfiguration 生命周期配置的xml格式
+type lifecycleConfiguration struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Rules   []lifecycleRule `xml:"Rule"`
+}
+
+type lifecycleRule struct {
+	ID                             string               `xml:"ID,omitempty"`
+	Prefix                         string               `xml:"Prefix"`
+	Status                         string               `xml:"Status"`
+	Expiration                     *lifecycleExpiration `xml:"Expiration,omitempty"`
+	AbortIncompleteMultipartUpload *lifecycleAbort      `xml:"AbortIncompleteMultipartUpload,omitempty"`
+}
+
+type lifecycleExpiration struct {
+	Days int    `xml:"Days,omitempty"`
+	Date string `xml:"Date,omitempty"`
+}
+
+type lifecycleAbort struct {
+	DaysAfterInitiation int `xml:"DaysAfterInitiation"`
+}
+
+// GetBucketLifecycle 获取bucket生命周期规则，没有设置时返回空，bucket不存在等其他错误照常返回
+func (s *SCS) GetBucketLifecycle(name string) ([]LifecycleRule, error) {
+	var params = make(map[string][]string)
+	params["lifecycle"] = []string{""}
+	req := &client.Request{
+		Method: "GET",
+		Bucket: name,
+		Path:   "/",
+		Params: params,
+	}
+	_, rc, err := s.c.Query(req)
+	defer rc.Close()
+	if err != nil {
+		if isErrorCode(err, "NoSuchLifecycleConfiguration") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	bts, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	var conf lifecycleConfiguration
+	if err := xml.Unmarshal(bts, &conf); err != nil {
+		return nil, err
+	}
+	rules := make([]LifecycleRule, 0, len(conf.Rules))
+	for _, r := range conf.Rules {
+		rule := LifecycleRule{ID: r.ID, Prefix: r.Prefix, Disabled: r.Status != "Enabled"}
+		if r.Expiration != nil {
+			rule.ExpirationDays = r.Expiration.Days
+			if r.Expiration.Date != "" {
+				if rule.ExpirationDate, err = parseTime(r.Expiration.Date); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if r.AbortIncompleteMultipartUpload != nil {
+			rule.AbortIncompleteUploadDays = r.AbortIncompleteMultipartUpload.DaysAfterInitiation
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// PutBucketLifecycle 设置bucket生命周期规则，覆盖已有规则
+func (s *SCS) PutBucketLifecycle(name string, rules []LifecycleRule) error {
+	var conf lifecycleConfiguration
+	for _, rule := range rules {
+		r := lifecycleRule{ID: rule.ID, Prefix: rule.Prefix, Status: "Enabled"}
+		if rule.Disabled {
+			r.Status = "Disabled"
+		}
+		if rule.ExpirationDays > 0 || !rule.ExpirationDate.IsZero() {
+			r.Expiration = &lifecycleExpiration{Days: rule.ExpirationDays}
+			if !rule.ExpirationDate.IsZero() {
+				r.Expiration.Date = rule.ExpirationDate.UTC().Format(time.RFC3339)
+			}
+		}
+		if rule.AbortIncompleteUploadDays > 0 {
+			r.AbortIncompleteMultipartUpload = &lifecycleAbort{DaysAfterInitiation: rule.AbortIncompleteUploadDays}
+		}
+		conf.Rules = append(conf.Rules, r)
+	}
+	data, err := xml.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(data)
+	var params = make(map[string][]string)
+	params["lifecycle"] = []string{""}
+	var headers = make(http.Header)
+	headers.Set("Content-Type", "application/xml")
+	headers.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	headers.Set("Content-Length", strconv.Itoa(len(data)))
+	req := &client.Request{
+		Method:  "PUT",
+		Bucket:  name,
+		Path:    "/",
+		Params:  params,
+		Headers: headers,
+		Body:    bytes.NewReader(data),
+	}
+	_, body, err := s.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteBucketLifecycle 删除bucket生命周期规则
+func (s *SCS) DeleteBucketLifecycle(name string) error {
+	var params = make(map[string][]string)
+	params["lifecycle"] = []string{""}
+	req := &client.Request{
+		Method: "DELETE",
+		Bucket: name,
+		Path:   "/",
+		Params: params,
+	}
+	_, body, err := s.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// LifecycleReport ApplyLifecycle结果汇总
+type LifecycleReport struct {
+	// Actions 删除object的SyncDelete动作和取消分片上传的SyncAbort动作，SyncAbort的Path为UploadID
+	Actions []SyncAction
+	Deleted int
+	Aborted int
+	Failed  int
+}
+
+func (r *LifecycleReport) add(a SyncAction) {
+	r.Actions = append(r.Actions, a)
+	switch {
+	case a.Err != nil:
+		r.Failed++
+	case a.Op == SyncDelete:
+		r.Deleted++
+	case a.Op == SyncAbort:
+		r.Aborted++
+	}
+}
+
+// ApplyLifecycle 在客户端执行生命周期规则，列出并删除过期的object，取消超时的分片上传，
+// 用于不支持服务端生命周期的endpoint。dryRun为true时只生成报告
+func ApplyLifecycle(ctx context.Context, b *Bucket, rules []LifecycleRule, dryRun bool) (*LifecycleReport, error) {
+	var report LifecycleReport
+	now := time.Now()
+	seen := make(map[string]bool)
+	aborted := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		if rule.ExpirationDays > 0 || !rule.ExpirationDate.IsZero() {
+			if err := expireObjects(ctx, b, rule, now, dryRun, seen, &report); err != nil {
+				return &report, err
+			}
+		}
+		if rule.AbortIncompleteUploadDays > 0 {
+			err := b.walkUploads(ctx, rule.Prefix, func(u Upload) error {
+				if aborted[u.UploadID] {
+					return nil
+				}
+				initiated := u.InitiatedTime()
+				if initiated.IsZero() || now.Before(initiated.AddDate(0, 0, rule.AbortIncompleteUploadDays)) {
+					return nil
+				}
+				aborted[u.UploadID] = true
+				a := SyncAction{Op: SyncAbort, Key: u.Key, Path: u.UploadID}
+				if !dryRun {
+					a.Err = b.AbortMultipartUpload(u.Key, u.UploadID)
+				}
+				report.add(a)
+				return nil
+			})
+			if err != nil {
+				return &report, err
+			}
+		}
+	}
+	sort.SliceStable(report.Actions, func(i, j int) bool {
+		return report.Actions[i].Key < report.Actions[j].Key
+	})
+	if report.Failed > 0 {
+		return &report, fmt.Errorf("%d lifecycle actions failed", report.Failed)
+	}
+	return &report, nil
+}
+
+// expireObjects 删除rule.Prefix下过期的object
+func expireObjects(ctx context.Context, b *Bucket, rule LifecycleRule, now time.Time, dryRun bool, seen map[string]bool, report *LifecycleReport) error {
+	var expired []Object
+	flush := func() error {
+		if len(expired) == 0 {
+			return nil
+		}
+		var results []DeleteResult
+		if !dryRun {
+			keys := make([]string, len(expired))
+			for i, obj := range expired {
+				keys[i] = obj.Name
+			}
+			results, _ = b.deleteMany(ctx, keys)
+		}
+		for i, obj := range expired {
+			a := SyncAction{Op: SyncDelete, Key: obj.Name, Size: obj.Size}
+			if results != nil {
+				a.Err = results[i].Err
+			}
+			report.add(a)
+		}
+		expired = expired[:0]
+		return ctx.Err()
+	}
+	err := b.walk(ctx, rule.Prefix, func(obj Object) error {
+		if seen[obj.Name] || !rule.expired(obj.LastModifiedTime(), now) {
+			return nil
+		}
+		seen[obj.Name] = true
+		expired = append(expired, obj)
+		if len(expired) < DeleteBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,252 @@
+package scs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// BucketLogging bucket访问日志配置，TargetBucket为空表示未开启
+type BucketLogging struct {
+	// TargetBucket 日志写入的bucket
+	TargetBucket string `xml:"TargetBucket"`
+	// TargetPrefix 日志文件的key前缀
+	TargetPrefix string `xml:"TargetPrefix"`
+}
+
+// bucketLoggingStatus 访问日志配置的xml格式
+type bucketLoggingStatus struct {
+	XMLName        xml.Name       `xml:"BucketLoggingStatus"`
+	LoggingEnabled *BucketLogging `xml:"LoggingEnabled,omitempty"`
+}
+
+// GetBucketLogging 获取bucket访问日志配置
+func (s *SCS) GetBucketLogging(name string) (BucketLogging, error) {
+	var logging BucketLogging
+	var params = make(map[string][]string)
+	params["logging"] = []string{""}
+	req := &client.Request{
+		Method: "GET",
+		Bucket: name,
+		Path:   "/",
+		Params: params,
+	}
+	_, rc, err := s.c.Query(req)
+	defer rc.Close()
+	if err != nil {
+		return logging, err
+	}
+	bts, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return logging, err
+	}
+	var status bucketLoggingStatus
+	if err := xml.Unmarshal(bts, &status); err != nil {
+		return logging, err
+	}
+	if status.LoggingEnabled != nil {
+		logging = *status.LoggingEnabled
+	}
+	return logging, nil
+}
+
+// PutBucketLogging 设置bucket访问日志配置，logging.TargetBucket为空时关闭访问日志
+func (s *SCS) PutBucketLogging(name string, logging BucketLogging) error {
+	var status bucketLoggingStatus
+	if logging.TargetBucket != "" {
+		status.LoggingEnabled = &logging
+	}
+	data, err := xml.Marshal(status)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(data)
+	var params = make(map[string][]string)
+	params["logging"] = []string{""}
+	var headers = make(http.Header)
+	headers.Set("Content-Type", "application/xml")
+	headers.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	headers.Set("Content-Length", strconv.Itoa(len(data)))
+	req := &client.Request{
+		Method:  "PUT",
+		Bucket:  name,
+		Path:    "/",
+		Params:  params,
+		Headers: headers,
+		Body:    bytes.NewReader(data),
+	}
+	_, body, err := s.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// accessLogTimeLayout 访问日志中的时间格式
+const accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogRecord 访问日志中的一条记录，日志中为"-"的字段为空字符串或0
+type AccessLogRecord struct {
+	BucketOwner string
+	Bucket      string
+	Time        time.Time
+	RemoteIP    string
+	Requester   string
+	RequestID   string
+	// Operation 操作类型，如REST.GET.OBJECT
+	Operation  string
+	Key        string
+	RequestURI string
+	Status     int
+	ErrorCode  string
+	// BytesSent 响应body的字节数，用于统计流量
+	BytesSent  int64
+	ObjectSize int64
+	// TotalTime 请求总耗时
+	TotalTime time.Duration
+	Referer   string
+	UserAgent string
+}
+
+// ParseAccessLog 逐行解析访问日志，对每条记录调用fn，空行被忽略，fn返回error时停止
+func ParseAccessLog(r io.Reader, fn func(AccessLogRecord) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		rec, err := ParseAccessLogLine(text)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ParseAccessLogLine 解析一行访问日志。SCS投递的日志与S3 server access log格式相同，
+// 字段依次为bucket owner、bucket、[time]、remote ip、requester、request id、operation、key、
+// "request uri"、status、error code、bytes sent、object size、total time、turn-around time、
+// "referer"、"user agent"，之后新增的字段(version id、host id、签名版本等)被忽略
+func ParseAccessLogLine(line string) (AccessLogRecord, error) {
+	var rec AccessLogRecord
+	fields, err := splitAccessLog(line)
+	if err != nil {
+		return rec, err
+	}
+	if len(fields) < 15 {
+		return rec, fmt.Errorf("access log has %d fields, want at least 15", len(fields))
+	}
+	rec.BucketOwner = logField(fields[0])
+	rec.Bucket = logField(fields[1])
+	if rec.Time, err = time.Parse(accessLogTimeLayout, fields[2]); err != nil {
+		return rec, err
+	}
+	rec.RemoteIP = logField(fields[3])
+	rec.Requester = logField(fields[4])
+	rec.RequestID = logField(fields[5])
+	rec.Operation = logField(fields[6])
+	rec.Key = logField(fields[7])
+	rec.RequestURI = logField(fields[8])
+	if s := logField(fields[9]); s != "" {
+		if rec.Status, err = strconv.Atoi(s); err != nil {
+			return rec, fmt.Errorf("bad status %q", s)
+		}
+	}
+	rec.ErrorCode = logField(fields[10])
+	if rec.BytesSent, err = logInt(fields[11]); err != nil {
+		return rec, err
+	}
+	if rec.ObjectSize, err = logInt(fields[12]); err != nil {
+		return rec, err
+	}
+	ms, err := logInt(fields[13])
+	if err != nil {
+		return rec, err
+	}
+	rec.TotalTime = time.Duration(ms) * time.Millisecond
+	if len(fields) > 15 {
+		rec.Referer = logField(fields[15])
+	}
+	if len(fields) > 16 {
+		rec.UserAgent = logField(fields[16])
+	}
+	return rec, nil
+}
+
+// splitAccessLog 按空格拆分字段，[...]和"..."中的空格不拆分，引号中的\"为转义的引号
+func splitAccessLog(line string) ([]string, error) {
+	var fields []string
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case ' ':
+			i++
+		case '[':
+			end := strings.IndexByte(line[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ at %d", i)
+			}
+			fields = append(fields, line[i+1:i+end])
+			i += end + 1
+		case '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(line) && line[j] != '"'; j++ {
+				if line[j] == '\\' && j+1 < len(line) {
+					j++
+				}
+				b.WriteByte(line[j])
+			}
+			if j >= len(line) {
+				return nil, fmt.Errorf("unterminated quote at %d", i)
+			}
+			fields = append(fields, b.String())
+			i = j + 1
+		default:
+			end := strings.IndexByte(line[i:], ' ')
+			if end < 0 {
+				end = len(line) - i
+			}
+			fields = append(fields, line[i:i+end])
+			i += end
+		}
+	}
+	return fields, nil
+}
+
+// logField 将表示空值的"-"转为空字符串
+func logField(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+func logInt(s string) (int64, error) {
+	if s = logField(s); s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad number %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,132 @@
+package scs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// S3 server access log文档中的示例，包含17个字段之后新增的字段
+const s3SampleLog = `79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be awsexamplebucket1 [06/Feb/2019:00:00:38 +0000] 192.0.2.3 79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be 3E57427F3EXAMPLE REST.GET.VERSIONING - "GET /awsexamplebucket1?versioning HTTP/1.1" 200 - 113 - 7 - "-" "S3Console/0.4" - s9lzHYrFp76ZVxRcpX9+5cjAnEH2ROuNkd2BHfIa6UkFVdtjf5mKR3/eTPFvsiP/XV/VLi31234= SigV2 ECDHE-RSA-AES128-GCM-SHA256 AuthHeader awsexamplebucket1.s3.us-west-1.amazonaws.com TLSV1.1`
+
+func TestParseAccessLogLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want AccessLogRecord
+	}{
+		{
+			name: "s3 sample",
+			line: s3SampleLog,
+			want: AccessLogRecord{
+				BucketOwner: "79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be",
+				Bucket:      "awsexamplebucket1",
+				Time:        time.Date(2019, 2, 6, 0, 0, 38, 0, time.UTC),
+				RemoteIP:    "192.0.2.3",
+				Requester:   "79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be",
+				RequestID:   "3E57427F3EXAMPLE",
+				Operation:   "REST.GET.VERSIONING",
+				RequestURI:  "GET /awsexamplebucket1?versioning HTTP/1.1",
+				Status:      200,
+				BytesSent:   113,
+				TotalTime:   7 * time.Millisecond,
+				UserAgent:   "S3Console/0.4",
+			},
+		},
+		{
+			name: "object download with referer",
+			line: `8f9c2d7e5a1b4c3d mybucket [19/Oct/2026:10:20:30 +0800] 10.0.0.8 - 0a1b2c3d REST.GET.OBJECT img/a%20b.png "GET /mybucket/img/a%20b.png HTTP/1.1" 206 - 1024 40960 15 3 "https://www.example.com/page?q=\"x\"" "Mozilla/5.0 (X11; Linux x86_64)"`,
+			want: AccessLogRecord{
+				BucketOwner: "8f9c2d7e5a1b4c3d",
+				Bucket:      "mybucket",
+				Time:        time.Date(2026, 10, 19, 2, 20, 30, 0, time.UTC),
+				RemoteIP:    "10.0.0.8",
+				RequestID:   "0a1b2c3d",
+				Operation:   "REST.GET.OBJECT",
+				Key:         "img/a%20b.png",
+				RequestURI:  "GET /mybucket/img/a%20b.png HTTP/1.1",
+				Status:      206,
+				BytesSent:   1024,
+				ObjectSize:  40960,
+				TotalTime:   15 * time.Millisecond,
+				Referer:     `https://www.example.com/page?q="x"`,
+				UserAgent:   "Mozilla/5.0 (X11; Linux x86_64)",
+			},
+		},
+		{
+			name: "error without referer and user agent",
+			line: `8f9c2d7e5a1b4c3d mybucket [19/Oct/2026:10:20:31 +0800] 10.0.0.9 - 0a1b2c3e REST.GET.OBJECT missing "GET /mybucket/missing HTTP/1.1" 404 NoSuchKey 243 - 2 -`,
+			want: AccessLogRecord{
+				BucketOwner: "8f9c2d7e5a1b4c3d",
+				Bucket:      "mybucket",
+				Time:        time.Date(2026, 10, 19, 2, 20, 31, 0, time.UTC),
+				RemoteIP:    "10.0.0.9",
+				RequestID:   "0a1b2c3e",
+				Operation:   "REST.GET.OBJECT",
+				Key:         "missing",
+				RequestURI:  "GET /mybucket/missing HTTP/1.1",
+				Status:      404,
+				ErrorCode:   "NoSuchKey",
+				BytesSent:   243,
+				TotalTime:   2 * time.Millisecond,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAccessLogLine(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Time.Equal(tt.want.Time) {
+				t.Fatalf("time %v, want %v", got.Time, tt.want.Time)
+			}
+			got.Time = tt.want.Time
+			if got != tt.want {
+				t.Fatalf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAccessLogLineErrors(t *testing.T) {
+	for _, line := range []string{
+		`owner bucket [06/Feb/2019:00:00:38 +0000] 192.0.2.3 - id REST.GET.OBJECT key "GET /key HTTP/1.1" 200 -`,
+		`owner bucket [06/Feb/2019:00:00:38 +0000 192.0.2.3 - id REST.GET.OBJECT key "GET /key HTTP/1.1" 200 - 1 1 1 1`,
+		`owner bucket [06/Feb/2019:00:00:38 +0000] 192.0.2.3 - id REST.GET.OBJECT key "GET /key HTTP/1.1 200 - 1 1 1 1`,
+		`owner bucket [2019-02-06T00:00:38Z] 192.0.2.3 - id REST.GET.OBJECT key "GET /key HTTP/1.1" 200 - 1 1 1 1`,
+		`owner bucket [06/Feb/2019:00:00:38 +0000] 192.0.2.3 - id REST.GET.OBJECT key "GET /key HTTP/1.1" OK - 1 1 1 1`,
+		`owner bucket [06/Feb/2019:00:00:38 +0000] 192.0.2.3 - id REST.GET.OBJECT key "GET /key HTTP/1.1" 200 - 1k 1 1 1`,
+	} {
+		if _, err := ParseAccessLogLine(line); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
+func TestParseAccessLog(t *testing.T) {
+	input := s3SampleLog + "\n\n" + s3SampleLog + "\n"
+	var n int
+	if err := ParseAccessLog(strings.NewReader(input), func(rec AccessLogRecord) error {
+		n++
+		return nil
+	}); err != nil || n != 2 {
+		t.Fatalf("parsed %d records, err %v", n, err)
+	}
+
+	err := ParseAccessLog(strings.NewReader(s3SampleLog+"\nbad line\n"), func(AccessLogRecord) error { return nil })
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Fatalf("bad line error %v", err)
+	}
+
+	stop := errors.New("stop")
+	n = 0
+	err = ParseAccessLog(strings.NewReader(input), func(AccessLogRecord) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Fatalf("callback error %v after %d records", err, n)
+	}
+}
@@ -0,0 +1,211 @@
+package scs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MigrateOptions Migrate可选参数
+type MigrateOptions struct {
+	// Include 只迁移匹配的相对key，规则同SyncOptions.Include
+	Include []string
+	// Exclude 跳过匹配的相对key
+	Exclude []string
+	// DryRun 只生成报告，不执行任何修改
+	DryRun bool
+	// Concurrency 并发数，默认4
+	Concurrency int
+	// StateFile 记录已完成object的状态文件，中断后再次运行时跳过已完成且未变化的object
+	StateFile string
+	// Verify 迁移完成后列出目标prefix并比较MD5
+	Verify bool
+}
+
+// MigrateCheck 一个object的校验结果
+type MigrateCheck struct {
+	SrcKey  string
+	DstKey  string
+	SrcMD5  string
+	DstMD5  string
+	SrcSize int64
+	DstSize int64
+	// OK 大小一致，且两端都不是分片上传时MD5也一致
+	OK bool
+}
+
+// MigrateReport 迁移结果汇总
+type MigrateReport struct {
+	// Actions 每个object的SyncCopy或SyncSkip动作
+	Actions []SyncAction
+	Copied  int
+	Skipped int
+	Failed  int
+	// Bytes 复制的字节数
+	Bytes int64
+	// Checks Verify为true时每个object的校验结果
+	Checks     []MigrateCheck
+	Mismatched int
+}
+
+func (r *MigrateReport) add(a SyncAction) {
+	r.Actions = append(r.Actions, a)
+	switch {
+	case a.Err != nil:
+		r.Failed++
+	case a.Op == SyncCopy:
+		r.Copied++
+		r.Bytes += a.Size
+	case a.Op == SyncSkip:
+		r.Skipped++
+	}
+}
+
+// migrateState 状态文件中一个object的记录
+type migrateState struct {
+	DstKey string `json:"DstKey"`
+	MD5    string `json:"MD5"`
+}
+
+// Migrate 将src账号srcBucket中srcPrefix下的object复制到dst账号dstBucket的dstPrefix下。
+// 两端属于同一账号时使用服务端复制，否则经客户端流式传输，保留x-amz-meta-*和Content-Type、Cache-Control等请求头。
+func Migrate(ctx context.Context, src *SCS, srcBucket, srcPrefix string, dst *SCS, dstBucket, dstPrefix string, opts *MigrateOptions) (*MigrateReport, error) {
+	var o MigrateOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	sb := src.Bucket(srcBucket)
+	db := dst.Bucket(dstBucket)
+	sameAccount := src.c.AccessKey() == dst.c.AccessKey() && src.c.Endpoint() == dst.c.Endpoint()
+
+	state := make(map[string]migrateState)
+	cp, err := loadCheckpoint(o.StateFile, o.DryRun, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	var report MigrateReport
+	var mu sync.Mutex
+	var migrated []Object
+	pool := newWorkerPool(ctx, o.Concurrency)
+	err = sb.walk(ctx, srcPrefix, func(obj Object) error {
+		rel := strings.TrimPrefix(obj.Name, srcPrefix)
+		if !matchFilters(rel, o.Include, o.Exclude) {
+			return nil
+		}
+		dstKey := dstPrefix + rel
+		migrated = append(migrated, obj)
+		var skip bool
+		cp.view(func() {
+			st, done := state[obj.Name]
+			skip = done && st.DstKey == dstKey && st.MD5 == obj.MD5
+		})
+		if skip {
+			mu.Lock()
+			report.add(SyncAction{Op: SyncSkip, Key: obj.Name, Path: dstKey, Size: obj.Size})
+			mu.Unlock()
+			return nil
+		}
+		return pool.submit(func() {
+			a := SyncAction{Op: SyncCopy, Key: obj.Name, Path: dstKey, Size: obj.Size}
+			if !o.DryRun {
+				if sameAccount {
+					a.Err = db.copyObject(ctx, dstKey, srcBucket, obj.Name, nil)
+				} else {
+					a.Err = streamCopy(ctx, sb, obj.Name, db, dstKey)
+				}
+			}
+			mu.Lock()
+			report.add(a)
+			mu.Unlock()
+			if a.Err == nil && !o.DryRun {
+				cp.update(func() { state[obj.Name] = migrateState{DstKey: dstKey, MD5: obj.MD5} })
+			}
+		})
+	})
+	pool.wait()
+	if serr := cp.save(); err == nil {
+		err = serr
+	}
+	if err == nil && o.Verify && !o.DryRun {
+		err = verifyMigration(ctx, &report, migrated, srcPrefix, db, dstPrefix)
+	}
+	sort.Slice(report.Actions, func(i, j int) bool {
+		return report.Actions[i].Key < report.Actions[j].Key
+	})
+	if err != nil {
+		return &report, err
+	}
+	if report.Failed > 0 || report.Mismatched > 0 {
+		return &report, fmt.Errorf("%d migrate actions failed, %d objects mismatched", report.Failed, report.Mismatched)
+	}
+	return &report, nil
+}
+
+// streamCopy 经客户端从sb复制object到db，按原样传输object中保存的字节，
+// 保留x-amz-meta-*、Content-Type、Cache-Control、Content-Disposition和Content-Encoding
+func streamCopy(ctx context.Context, sb *Bucket, srcKey string, db *Bucket, dstKey string) error {
+	meta, err := sb.head(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	headers := make(map[string]string)
+	for k, v := range meta.XAmzMeta {
+		headers[k] = v
+	}
+	for k, v := range map[string]string{
+		"Content-Type":        meta.ContentType,
+		"Cache-Control":       meta.CacheControl,
+		"Content-Disposition": meta.ContentDisposition,
+		"Content-Encoding":    meta.ContentEncoding,
+	} {
+		if v != "" {
+			headers[k] = v
+		}
+	}
+	_, body, err := sb.getObject(ctx, srcKey, "", nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return db.putStream(ctx, dstKey, headers, body)
+}
+
+// verifyMigration 比较源和目标object的大小和MD5。分片上传的ETag不是内容的MD5，
+// 超过MD5Threshold的object跨账号复制后也会变为分片上传，任一端是分片上传时只比较大小
+func verifyMigration(ctx context.Context, report *MigrateReport, migrated []Object, srcPrefix string, db *Bucket, dstPrefix string) error {
+	dstObjs := make(map[string]Object)
+	err := db.walk(ctx, dstPrefix, func(obj Object) error {
+		dstObjs[obj.Name] = obj
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, obj := range migrated {
+		dstKey := dstPrefix + strings.TrimPrefix(obj.Name, srcPrefix)
+		dst, ok := dstObjs[dstKey]
+		check := MigrateCheck{
+			SrcKey:  obj.Name,
+			DstKey:  dstKey,
+			SrcMD5:  obj.MD5,
+			DstMD5:  dst.MD5,
+			SrcSize: obj.Size,
+			DstSize: dst.Size,
+			OK:      ok && obj.Size == dst.Size,
+		}
+		if check.OK && !isMultipartETag(obj.MD5) && !isMultipartETag(dst.MD5) {
+			check.OK = strings.EqualFold(obj.MD5, dst.MD5)
+		}
+		if !check.OK {
+			report.Mismatched++
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	return nil
+}
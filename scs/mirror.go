@@ -0,0 +1,252 @@
+package scs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mirrorTempSuffix 下载中的临时文件后缀
+const mirrorTempSuffix = ".scs-download"
+
+// MirrorOptions Mirror可选参数
+type MirrorOptions struct {
+	SyncOptions
+	// StateFile 记录已下载object的MD5和Last-Modified的状态文件，为空时通过本地文件的大小、修改时间和MD5判断
+	StateFile string
+}
+
+// mirrorState 状态文件中一个object的记录
+type mirrorState struct {
+	Size         int64  `json:"Size"`
+	MD5          string `json:"MD5"`
+	LastModified string `json:"Last-Modified"`
+	// LocalSize 本地文件大小，压缩的object解压后与Size不同
+	LocalSize int64 `json:"LocalSize,omitempty"`
+}
+
+// Mirror 将bucket的prefix镜像到本地目录，只下载新增或有变化的object，并按Last-Modified设置文件修改时间
+// 包含".."或以"/"开头等会解析到localDir之外的key不会下载，在报告中记为失败的SyncSkip
+// 通过WithCompression压缩的object解压后保存
+func Mirror(ctx context.Context, b *Bucket, prefix string, localDir string, opts *MirrorOptions) (*SyncReport, error) {
+	var o MirrorOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	ctx = withLimiters(ctx, o.ReadLimiter, o.WriteLimiter)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	root, err := filepath.Abs(localDir)
+	if err != nil {
+		return nil, err
+	}
+	var stateFile string
+	if o.StateFile != "" {
+		if stateFile, err = filepath.Abs(o.StateFile); err != nil {
+			return nil, err
+		}
+	}
+	state := make(map[string]mirrorState)
+	cp, err := loadCheckpoint(o.StateFile, o.DryRun, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	var report SyncReport
+	var mu sync.Mutex
+	remote := make(map[string]bool)
+	pool := newWorkerPool(ctx, o.Concurrency)
+	err = b.walk(ctx, prefix, func(obj Object) error {
+		rel := strings.TrimPrefix(obj.Name, prefix)
+		if rel == "" || strings.HasSuffix(rel, "/") || !matchFilters(rel, o.Include, o.Exclude) {
+			return nil
+		}
+		p, ok := mirrorPath(root, rel)
+		if !ok || p == stateFile {
+			mu.Lock()
+			report.add(SyncAction{Op: SyncSkip, Key: obj.Name, Size: obj.Size, Err: fmt.Errorf("key %s resolves outside %s", obj.Name, localDir)})
+			mu.Unlock()
+			return nil
+		}
+		remote[p] = true
+		var st mirrorState
+		var hasState bool
+		cp.view(func() { st, hasState = state[obj.Name] })
+		return pool.submit(func() {
+			a := mirrorObject(ctx, b, obj, p, st, hasState, &o)
+			mu.Lock()
+			report.add(a)
+			mu.Unlock()
+			if a.Err == nil && !o.DryRun {
+				st := mirrorState{Size: obj.Size, MD5: obj.MD5, LastModified: obj.LastModified}
+				if info, err := os.Stat(p); err == nil {
+					st.LocalSize = info.Size()
+				}
+				cp.update(func() { state[obj.Name] = st })
+			}
+		})
+	})
+	pool.wait()
+
+	if err == nil && o.Delete {
+		err = filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.Mode().IsRegular() || remote[p] || p == stateFile || strings.HasSuffix(p, mirrorTempSuffix) {
+				return nil
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			if !matchFilters(filepath.ToSlash(rel), o.Include, o.Exclude) {
+				return nil
+			}
+			a := SyncAction{Op: SyncDelete, Key: prefix + filepath.ToSlash(rel), Path: p, Size: info.Size()}
+			if !o.DryRun {
+				a.Err = os.Remove(p)
+				if a.Err == nil {
+					cp.update(func() { delete(state, a.Key) })
+				}
+			}
+			report.add(a)
+			return nil
+		})
+	}
+	if serr := cp.save(); err == nil {
+		err = serr
+	}
+	sort.Slice(report.Actions, func(i, j int) bool {
+		return report.Actions[i].Key < report.Actions[j].Key
+	})
+	if err != nil {
+		return &report, err
+	}
+	if report.Failed > 0 {
+		return &report, fmt.Errorf("%d mirror actions failed", report.Failed)
+	}
+	return &report, nil
+}
+
+// mirrorPath 返回相对key rel在root下的本地路径，rel为绝对路径、包含".."或解析后不在root下时返回false
+func mirrorPath(root, rel string) (string, bool) {
+	if path.IsAbs(rel) || filepath.IsAbs(filepath.FromSlash(rel)) || filepath.VolumeName(filepath.FromSlash(rel)) != "" {
+		return "", false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(filepath.FromSlash(rel)), "/") {
+		if part == ".." {
+			return "", false
+		}
+	}
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	r, err := filepath.Rel(root, p)
+	if err != nil || r == "." || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return p, true
+}
+
+// mirrorObject 比较并下载单个object
+func mirrorObject(ctx context.Context, b *Bucket, obj Object, p string, st mirrorState, hasState bool, o *MirrorOptions) SyncAction {
+	a := SyncAction{Op: SyncDownload, Key: obj.Name, Path: p, Size: obj.Size}
+	modtime := obj.LastModifiedTime()
+	if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() {
+		sameSize := info.Size() == obj.Size
+		switch {
+		case hasState:
+			if st.Size == obj.Size && st.MD5 == obj.MD5 && st.LastModified == obj.LastModified && (sameSize || info.Size() == st.LocalSize) {
+				a.Op = SyncSkip
+				return a
+			}
+		case !modtime.IsZero() && info.ModTime().Equal(modtime) && (sameSize || decodedSize(ctx, b, obj.Name) == info.Size()):
+			a.Op = SyncSkip
+			return a
+		case sameSize && obj.MD5 != "":
+			if md5sum, _, err := fileHashes(p); err == nil && strings.EqualFold(md5sum, obj.MD5) {
+				a.Op = SyncSkip
+				if !o.DryRun && !modtime.IsZero() {
+					os.Chtimes(p, modtime, modtime)
+				}
+				return a
+			}
+		}
+	}
+	if o.DryRun {
+		return a
+	}
+	tracker := newProgressTracker(o.Progress, obj.Name, obj.Size)
+	tracker.started()
+	a.Err = tracker.finish(downloadFile(withProgress(ctx, tracker), b, obj, p, modtime))
+	return a
+}
+
+// decodedSize 返回压缩object解压后的大小，没有压缩或大小未知时返回-1
+func decodedSize(ctx context.Context, b *Bucket, key string) int64 {
+	meta, err := b.head(ctx, key)
+	if err != nil {
+		return -1
+	}
+	_, size := compression(meta)
+	return size
+}
+
+// downloadFile 下载到临时文件，校验MD5后重命名为目标文件，压缩的object解压后保存
+func downloadFile(ctx context.Context, b *Bucket, obj Object, p string, modtime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	header, body, err := b.getObject(ctx, obj.Name, "", nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	// MD5按object中保存的字节计算
+	h := md5.New()
+	raw := io.TeeReader(body, h)
+	data, err := decompressBody(header, ioutil.NopCloser(raw))
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+	tmp := p + mirrorTempSuffix
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, data)
+	if err == nil {
+		_, err = io.Copy(ioutil.Discard, raw)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && obj.MD5 != "" && !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), obj.MD5) {
+		err = fmt.Errorf("md5 mismatch for %s", obj.Name)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if !modtime.IsZero() {
+		os.Chtimes(tmp, modtime, modtime)
+	}
+	return os.Rename(tmp, p)
+}
@@ -0,0 +1,169 @@
+package scs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MoveOptions MovePrefix可选参数
+type MoveOptions struct {
+	// Include 只移动匹配的相对key，规则同SyncOptions.Include
+	Include []string
+	// Exclude 跳过匹配的相对key
+	Exclude []string
+	// DryRun 只生成报告，不执行任何修改
+	DryRun bool
+	// Concurrency 并发数，默认4
+	Concurrency int
+	// Journal 记录已复制但未删除源object的journal文件，中断后再次运行时跳过已完成的复制，全部成功后删除
+	Journal string
+}
+
+// MoveReport MovePrefix结果汇总
+type MoveReport struct {
+	// Actions 每个object的SyncMove动作，Path为目标key
+	Actions []SyncAction
+	Moved   int
+	Failed  int
+}
+
+func (r *MoveReport) add(a SyncAction) {
+	r.Actions = append(r.Actions, a)
+	if a.Err != nil {
+		r.Failed++
+		return
+	}
+	r.Moved++
+}
+
+// moveState journal中一个已复制object的记录
+type moveState struct {
+	DstKey string `json:"DstKey"`
+	MD5    string `json:"MD5"`
+}
+
+// Move 将src移动到dst，服务端复制并通过Head校验后删除src，复制或校验失败时保留src
+func (b *Bucket) Move(src, dst string) error {
+	if src == dst {
+		return fmt.Errorf("move %s to itself", src)
+	}
+	ctx := context.Background()
+	meta, err := b.stat(ctx, src)
+	if err != nil {
+		return err
+	}
+	obj := Object{Name: src, Size: meta.ContentLength, MD5: strings.Trim(meta.ETag, `"`)}
+	if err := b.copyObject(ctx, dst, b.Name, src, nil); err != nil {
+		return err
+	}
+	return b.verifyAndDelete(ctx, obj, dst)
+}
+
+// MovePrefix 将oldPrefix下的object并发移动到newPrefix下，prefix按目录处理，不以"/"结尾时自动补上，
+// 两个目录不能相同或互相包含
+func (b *Bucket) MovePrefix(ctx context.Context, oldPrefix, newPrefix string, opts *MoveOptions) (*MoveReport, error) {
+	var o MoveOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if oldPrefix != "" && !strings.HasSuffix(oldPrefix, "/") {
+		oldPrefix += "/"
+	}
+	if newPrefix != "" && !strings.HasSuffix(newPrefix, "/") {
+		newPrefix += "/"
+	}
+	if strings.HasPrefix(newPrefix, oldPrefix) || strings.HasPrefix(oldPrefix, newPrefix) {
+		return nil, fmt.Errorf("move prefix %q to overlapping prefix %q", oldPrefix, newPrefix)
+	}
+
+	journal := make(map[string]moveState)
+	cp, err := loadCheckpoint(o.Journal, o.DryRun, &journal)
+	if err != nil {
+		return nil, err
+	}
+
+	var report MoveReport
+	var mu sync.Mutex
+	pool := newWorkerPool(ctx, o.Concurrency)
+	err = b.walk(ctx, oldPrefix, func(obj Object) error {
+		rel := strings.TrimPrefix(obj.Name, oldPrefix)
+		if !matchFilters(rel, o.Include, o.Exclude) {
+			return nil
+		}
+		dstKey := newPrefix + rel
+		var copied bool
+		cp.view(func() {
+			st, ok := journal[obj.Name]
+			copied = ok && st.DstKey == dstKey && st.MD5 == obj.MD5
+		})
+		return pool.submit(func() {
+			a := SyncAction{Op: SyncMove, Key: obj.Name, Path: dstKey, Size: obj.Size}
+			if !o.DryRun {
+				if !copied {
+					a.Err = b.copyObject(ctx, dstKey, b.Name, obj.Name, nil)
+					if a.Err == nil {
+						cp.update(func() { journal[obj.Name] = moveState{DstKey: dstKey, MD5: obj.MD5} })
+					}
+				}
+				if a.Err == nil {
+					a.Err = b.verifyAndDelete(ctx, obj, dstKey)
+				}
+				if a.Err == nil {
+					cp.update(func() { delete(journal, obj.Name) })
+				}
+			}
+			mu.Lock()
+			report.add(a)
+			mu.Unlock()
+		})
+	})
+	pool.wait()
+	var cerr error
+	if err == nil && report.Failed == 0 && len(journal) == 0 {
+		cerr = cp.remove()
+	} else {
+		cerr = cp.save()
+	}
+	if err == nil {
+		err = cerr
+	}
+	sort.Slice(report.Actions, func(i, j int) bool {
+		return report.Actions[i].Key < report.Actions[j].Key
+	})
+	if err != nil {
+		return &report, err
+	}
+	if report.Failed > 0 {
+		return &report, fmt.Errorf("%d move actions failed", report.Failed)
+	}
+	return &report, nil
+}
+
+// verifyAndDelete 校验dst的大小与源object一致后删除源object，两端ETag都是内容的MD5时同时比较ETag。
+// 分片上传的ETag不是内容的MD5，服务端复制后也可能变化，这时只比较大小
+func (b *Bucket) verifyAndDelete(ctx context.Context, obj Object, dst string) error {
+	meta, err := b.stat(ctx, dst)
+	if err != nil {
+		return err
+	}
+	etag := strings.Trim(meta.ETag, `"`)
+	mismatch := meta.ContentLength != obj.Size
+	if etag != "" && obj.MD5 != "" && !isMultipartETag(etag) && !isMultipartETag(obj.MD5) {
+		mismatch = mismatch || !strings.EqualFold(etag, obj.MD5)
+	}
+	if mismatch {
+		return fmt.Errorf("moved object %s does not match %s", dst, obj.Name)
+	}
+	return b.deleteObject(ctx, obj.Name)
+}
+
+// isMultipartETag 判断是否为分片上传的ETag，形如"md5-分片数"
+func isMultipartETag(etag string) bool {
+	return strings.Contains(etag, "-")
+}
@@ -0,0 +1,33 @@
+package scs
+
+import "context"
+
+// Option Put、Get等单次操作的可选参数
+type Option func(*options)
+
+type options struct {
+	codec        string
+	progress     ProgressListener
+	readLimiter  *RateLimiter
+	writeLimiter *RateLimiter
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// context 将单次操作的限速等参数放入ctx
+func (o *options) context(ctx context.Context) context.Context {
+	return withLimiters(ctx, o.readLimiter, o.writeLimiter)
+}
+
+// WithCompression 上传时使用codec压缩数据，codec需为CodecGzip或通过RegisterCodec注册的名称
+func WithCompression(codec string) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
@@ -0,0 +1,44 @@
+package scs
+
+import (
+	"context"
+	"sync"
+)
+
+// workerPool 固定数量的goroutine并发执行提交的任务
+type workerPool struct {
+	ctx   context.Context
+	tasks chan func()
+	wg    sync.WaitGroup
+}
+
+// newWorkerPool 启动n个goroutine执行任务，ctx结束后不再接受新任务
+func newWorkerPool(ctx context.Context, n int) *workerPool {
+	p := &workerPool{ctx: ctx, tasks: make(chan func(), n)}
+	for i := 0; i < n; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for task := range p.tasks {
+				task()
+			}
+		}()
+	}
+	return p
+}
+
+// submit 提交任务，等待空闲goroutine时ctx结束则返回ctx的错误
+func (p *workerPool) submit(task func()) error {
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+}
+
+// wait 停止接受任务并等待已提交的任务完成，只能调用一次
+func (p *workerPool) wait() {
+	close(p.tasks)
+	p.wg.Wait()
+}
@@ -0,0 +1,176 @@
+package scs
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// ProgressEventType 进度事件类型
+type ProgressEventType int
+
+// 进度事件类型
+const (
+	ProgressStarted ProgressEventType = iota
+	ProgressTransferred
+	ProgressPartCompleted
+	ProgressCompleted
+	ProgressFailed
+)
+
+// ProgressEvent 进度事件
+type ProgressEvent struct {
+	Type ProgressEventType
+	Key  string
+	// TotalBytes 总字节数，未知时为-1
+	TotalBytes int64
+	// TransferredBytes 已传输的字节数
+	TransferredBytes int64
+	// PartNumber ProgressPartCompleted事件对应的分片号
+	PartNumber int
+	// Err ProgressFailed事件对应的错误
+	Err error
+}
+
+// ProgressListener 接收上传和下载的进度事件，同一次传输的事件按顺序串行回调
+type ProgressListener interface {
+	ProgressChanged(event *ProgressEvent)
+}
+
+// ProgressListenerFunc 函数形式的ProgressListener
+type ProgressListenerFunc func(event *ProgressEvent)
+
+// ProgressChanged 实现ProgressListener
+func (f ProgressListenerFunc) ProgressChanged(event *ProgressEvent) {
+	f(event)
+}
+
+// WithProgress 传输过程中向l报告进度
+func WithProgress(l ProgressListener) Option {
+	return func(o *options) {
+		o.progress = l
+	}
+}
+
+// progressTracker 统计一次传输的进度并回调listener，nil时所有方法为空操作
+type progressTracker struct {
+	mu          sync.Mutex
+	l           ProgressListener
+	key         string
+	total       int64
+	transferred int64
+	finished    bool
+}
+
+func newProgressTracker(l ProgressListener, key string, total int64) *progressTracker {
+	if l == nil {
+		return nil
+	}
+	return &progressTracker{l: l, key: key, total: total}
+}
+
+func (t *progressTracker) publish(typ ProgressEventType, partNumber int, err error) {
+	t.l.ProgressChanged(&ProgressEvent{
+		Type:             typ,
+		Key:              t.key,
+		TotalBytes:       t.total,
+		TransferredBytes: t.transferred,
+		PartNumber:       partNumber,
+		Err:              err,
+	})
+}
+
+func (t *progressTracker) started() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.publish(ProgressStarted, 0, nil)
+}
+
+func (t *progressTracker) setTotal(total int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.total = total
+	t.mu.Unlock()
+}
+
+func (t *progressTracker) add(n int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transferred += n
+	t.publish(ProgressTransferred, 0, nil)
+}
+
+func (t *progressTracker) partCompleted(partNumber int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.publish(ProgressPartCompleted, partNumber, nil)
+}
+
+// finish 根据err发送completed或failed事件，只发送一次，返回err
+func (t *progressTracker) finish(err error) error {
+	if t == nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished {
+		return err
+	}
+	t.finished = true
+	if err != nil {
+		t.publish(ProgressFailed, 0, err)
+	} else {
+		t.publish(ProgressCompleted, 0, nil)
+	}
+	return err
+}
+
+// countFunc 返回用于client.Request的计数回调
+func (t *progressTracker) countFunc() func(n int64) {
+	if t == nil {
+		return nil
+	}
+	return t.add
+}
+
+type progressContextKey struct{}
+
+// withProgress 将tracker放入ctx，内部的上传下载请求从ctx中取出tracker计数
+func withProgress(ctx context.Context, t *progressTracker) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressContextKey{}, t)
+}
+
+func progressFromContext(ctx context.Context) *progressTracker {
+	t, _ := ctx.Value(progressContextKey{}).(*progressTracker)
+	return t
+}
+
+// progressBody 下载的body读到EOF时发送completed事件，出错时发送failed事件
+type progressBody struct {
+	io.ReadCloser
+	t *progressTracker
+}
+
+func (p *progressBody) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	if err == io.EOF {
+		p.t.finish(nil)
+	} else if err != nil {
+		p.t.finish(err)
+	}
+	return n, err
+}
@@ -0,0 +1,54 @@
+package scs
+
+import (
+	"context"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// RateLimiter 按每秒字节数限速的令牌桶，限速可以在运行时通过SetLimit调整
+type RateLimiter = client.RateLimiter
+
+// NewRateLimiter 返回每秒允许bytesPerSec字节的RateLimiter，<=0表示不限速
+func NewRateLimiter(bytesPerSec int64) *RateLimiter {
+	return client.NewRateLimiter(bytesPerSec)
+}
+
+// SetRateLimit 设置全局的下载(read)和上传(write)限速，单位字节每秒，0表示不限速，可以在运行时调用
+func (s *SCS) SetRateLimit(read, write int64) {
+	s.c.SetRateLimit(read, write)
+}
+
+// WithRateLimiter 单次操作使用的限速器，覆盖全局限速，为nil时使用全局限速
+func WithRateLimiter(read, write *RateLimiter) Option {
+	return func(o *options) {
+		o.readLimiter = read
+		o.writeLimiter = write
+	}
+}
+
+type limiterContextKey struct{}
+
+type limiters struct {
+	read  *RateLimiter
+	write *RateLimiter
+}
+
+// withLimiters 将限速器放入ctx，内部的上传下载请求从ctx中取出
+func withLimiters(ctx context.Context, read, write *RateLimiter) context.Context {
+	if read == nil && write == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, limiterContextKey{}, limiters{read: read, write: write})
+}
+
+func limitersFromContext(ctx context.Context) (*RateLimiter, *RateLimiter) {
+	l, _ := ctx.Value(limiterContextKey{}).(limiters)
+	return l.read, l.write
+}
+
+// SetRequestLimit 设置每秒最多发起的请求数和同时进行的最大请求数，0表示不限制，可以在运行时调用。
+// 服务端返回限流错误时会自动退避，没有body的请求会重试
+func (s *SCS) SetRequestLimit(perSecond float64, maxInFlight int) {
+	s.c.SetRequestLimit(perSecond, maxInFlight)
+}
@@ -0,0 +1,228 @@
+package scs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// ErrObjectChanged 打开后object被覆盖，ETag不再匹配
+var ErrObjectChanged = errors.New("object changed since opened")
+
+// DefaultReadAhead ObjectReader顺序Read时默认预读的字节数
+const DefaultReadAhead = 1024 * 1024
+
+// ObjectReader 可随机读取的object句柄，实现io.ReaderAt、io.ReadSeeker和io.Closer。
+// 每次读取按需发起Range请求并通过If-Match固定打开时的ETag，object被覆盖时返回ErrObjectChanged。
+// ReadAt可以并发调用，Read和Seek不能并发调用。
+type ObjectReader struct {
+	b      *Bucket
+	key    string
+	meta   ObjectMeta
+	ctx    context.Context
+	cancel context.CancelFunc
+	// Read使用的偏移和预读缓冲
+	mu        sync.Mutex
+	offset    int64
+	readAhead int64
+	buf       []byte
+	bufOffset int64
+}
+
+var _ io.ReaderAt = (*ObjectReader)(nil)
+var _ io.ReadSeeker = (*ObjectReader)(nil)
+var _ io.Closer = (*ObjectReader)(nil)
+
+// Open 打开object用于随机读取，读到的是object中保存的原始字节，
+// 通过WithCompression压缩的object不能随机读取，返回ErrObjectCompressed，需使用Get读取解压后的内容
+func (b *Bucket) Open(key string) (*ObjectReader, error) {
+	meta, err := b.stat(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	if codec, _ := compression(meta); codec != "" {
+		return nil, ErrObjectCompressed
+	}
+	return b.openObject(key, meta), nil
+}
+
+// openObject 以Head得到的meta创建ObjectReader
+func (b *Bucket) openObject(key string, meta ObjectMeta) *ObjectReader {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ObjectReader{
+		b:         b,
+		key:       key,
+		meta:      meta,
+		ctx:       ctx,
+		cancel:    cancel,
+		readAhead: DefaultReadAhead,
+	}
+}
+
+// Meta 返回打开时object的meta
+func (r *ObjectReader) Meta() ObjectMeta {
+	return r.meta
+}
+
+// Size 返回object大小
+func (r *ObjectReader) Size() int64 {
+	return r.meta.ContentLength
+}
+
+// SetReadAhead 设置顺序Read时的预读字节数，0表示不预读
+func (r *ObjectReader) SetReadAhead(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	r.readAhead = n
+	r.buf = nil
+}
+
+// ReadAt 读取从off开始的len(p)字节
+func (r *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off >= r.Size() {
+		return 0, io.EOF
+	}
+	end := off + int64(len(p))
+	if end > r.Size() {
+		end = r.Size()
+	}
+	n, err := r.readRange(p[:end-off], off)
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Read 从当前偏移顺序读取，按预读大小缓冲
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if r.offset >= r.Size() {
+		return 0, io.EOF
+	}
+	if r.offset < r.bufOffset || r.offset >= r.bufOffset+int64(len(r.buf)) {
+		size := int64(len(p))
+		if size < r.readAhead {
+			size = r.readAhead
+		}
+		if size > r.Size()-r.offset {
+			size = r.Size() - r.offset
+		}
+		buf := make([]byte, size)
+		n, err := r.readRange(buf, r.offset)
+		if n == 0 {
+			r.buf = nil
+			if err == nil {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		r.buf = buf[:n]
+		r.bufOffset = r.offset
+	}
+	n := copy(p, r.buf[r.offset-r.bufOffset:])
+	r.offset += int64(n)
+	return n, nil
+}
+
+// Seek 设置下一次Read的偏移
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.Size()
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// Close 关闭句柄，取消进行中的请求
+func (r *ObjectReader) Close() error {
+	r.cancel()
+	r.mu.Lock()
+	r.buf = nil
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *ObjectReader) readRange(p []byte, off int64) (int, error) {
+	var headers = make(http.Header)
+	if r.meta.ETag != "" {
+		headers.Set("If-Match", r.meta.ETag)
+	}
+	header, body, err := r.b.getObject(r.ctx, r.key, fmt.Sprintf("%d-%d", off, off+int64(len(p))-1), headers)
+	if err != nil {
+		if e, ok := err.(*client.Error); ok && e.StatusCode == http.StatusPreconditionFailed {
+			return 0, ErrObjectChanged
+		}
+		return 0, err
+	}
+	defer body.Close()
+	// 忽略Range的服务端或代理会返回200和整个object，不能当作off开始的数据
+	start, _, _, err := parseContentRange(header.Get("Content-Range"))
+	if err != nil {
+		return 0, err
+	}
+	if start != off {
+		return 0, fmt.Errorf("range response starts at %d, want %d", start, off)
+	}
+	n, err := io.ReadFull(body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// parseContentRange 解析"bytes start-end/total"形式的Content-Range，total未知时为-1
+func parseContentRange(v string) (start, end, total int64, err error) {
+	if v == "" {
+		return 0, 0, 0, errors.New("missing Content-Range in range response")
+	}
+	rg := strings.TrimPrefix(v, "bytes ")
+	i := strings.IndexByte(rg, '-')
+	j := strings.IndexByte(rg, '/')
+	if rg == v || i < 0 || j < i {
+		return 0, 0, 0, fmt.Errorf("bad Content-Range %q", v)
+	}
+	if start, err = strconv.ParseInt(rg[:i], 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("bad Content-Range %q", v)
+	}
+	if end, err = strconv.ParseInt(rg[i+1:j], 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("bad Content-Range %q", v)
+	}
+	total = -1
+	if rg[j+1:] != "*" {
+		if total, err = strconv.ParseInt(rg[j+1:], 10, 64); err != nil {
+			return 0, 0, 0, fmt.Errorf("bad Content-Range %q", v)
+		}
+	}
+	return start, end, total, nil
+}
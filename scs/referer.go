@@ -0,0 +1,146 @@
+package scs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// RefererPolicy bucket防盗链规则，名单中的域名可以使用*通配符，如*.example.com
+type RefererPolicy struct {
+	// AllowEmpty 允许没有Referer的请求
+	AllowEmpty bool `json:"AllowEmptyReferer"`
+	// AllowList 允许的Referer域名，为空时只按DenyList判断
+	AllowList []string `json:"AllowList"`
+	// DenyList 禁止的Referer域名，优先于AllowList
+	DenyList []string `json:"DenyList"`
+}
+
+// Allowed 判断referer是否被规则允许，referer可以是完整url或域名
+func (p RefererPolicy) Allowed(referer string) bool {
+	referer = strings.TrimSpace(referer)
+	if referer == "" {
+		return p.AllowEmpty
+	}
+	host := refererHost(referer)
+	for _, pattern := range p.DenyList {
+		if matchReferer(pattern, host) {
+			return false
+		}
+	}
+	if len(p.AllowList) == 0 {
+		return true
+	}
+	for _, pattern := range p.AllowList {
+		if matchReferer(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBucketReferer 获取bucket防盗链规则
+func (s *SCS) GetBucketReferer(name string) (RefererPolicy, error) {
+	var policy RefererPolicy
+	var params = make(map[string][]string)
+	params["referer"] = []string{""}
+	params["formatter"] = []string{"json"}
+	req := &client.Request{
+		Method: "GET",
+		Bucket: name,
+		Path:   "/",
+		Params: params,
+	}
+	_, rc, err := s.c.Query(req)
+	defer rc.Close()
+	if err != nil {
+		return policy, err
+	}
+	bts, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(bts, &policy); err != nil {
+		return policy, err
+	}
+	return policy, nil
+}
+
+// PutBucketReferer 设置bucket防盗链规则，覆盖已有规则
+func (s *SCS) PutBucketReferer(name string, policy RefererPolicy) error {
+	var params = make(map[string][]string)
+	params["referer"] = []string{""}
+	params["formatter"] = []string{"json"}
+	bts, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(bts)
+	var headers = make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	headers.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	headers.Set("Content-Length", strconv.Itoa(len(bts)))
+	req := &client.Request{
+		Method:  "PUT",
+		Bucket:  name,
+		Path:    "/",
+		Params:  params,
+		Headers: headers,
+		Body:    bytes.NewReader(bts),
+	}
+	_, body, err := s.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// EvaluateReferer 在本地按policy判断带referer的请求是否被允许，不发送请求
+func (s *SCS) EvaluateReferer(policy RefererPolicy, referer string) bool {
+	return policy.Allowed(referer)
+}
+
+// refererHost 返回referer中的域名，不含端口
+func refererHost(referer string) string {
+	if !strings.Contains(referer, "://") {
+		referer = "http://" + referer
+	}
+	u, err := url.Parse(referer)
+	if err != nil {
+		return strings.ToLower(referer)
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// matchReferer 判断域名是否匹配规则，*匹配任意字符，规则不区分大小写
+func matchReferer(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if strings.Contains(pattern, "://") {
+		pattern = refererHost(pattern)
+	}
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == host
+	}
+	if !strings.HasPrefix(host, parts[0]) {
+		return false
+	}
+	host = host[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(host, part)
+		if i < 0 {
+			return false
+		}
+		host = host[i+len(part):]
+	}
+	return strings.HasSuffix(host, parts[len(parts)-1])
+}
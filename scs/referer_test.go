@@ -0,0 +1,63 @@
+package scs
+
+import "testing"
+
+func TestRefererPolicyAllowed(t *testing.T) {
+	policy := RefererPolicy{
+		AllowList: []string{"*.example.com", "example.com", "https://partner.org"},
+		DenyList:  []string{"bad.example.com"},
+	}
+	tests := []struct {
+		policy  RefererPolicy
+		referer string
+		want    bool
+	}{
+		{policy, "", false},
+		{policy, "   ", false},
+		{RefererPolicy{AllowEmpty: true}, "", true},
+		{policy, "https://www.example.com/page.html", true},
+		{policy, "http://WWW.Example.COM:8080/a?b=c", true},
+		{policy, "example.com", true},
+		{policy, "https://example.com", true},
+		{policy, "https://notexample.com", false},
+		{policy, "https://example.com.evil.net/", false},
+		{policy, "https://bad.example.com/x", false},
+		{policy, "http://partner.org/img", true},
+		{policy, "http://cdn.partner.org/img", false},
+		{RefererPolicy{DenyList: []string{"*.evil.net"}}, "https://a.evil.net", false},
+		{RefererPolicy{DenyList: []string{"*.evil.net"}}, "https://good.net", true},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Allowed(tt.referer); got != tt.want {
+			t.Errorf("%+v.Allowed(%q) = %v, want %v", tt.policy, tt.referer, got, tt.want)
+		}
+	}
+}
+
+func TestMatchReferer(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		want    bool
+	}{
+		{"example.com", "example.com", true},
+		{"Example.COM", "example.com", true},
+		{"example.com", "www.example.com", false},
+		{"*", "anything.net", true},
+		{"*.example.com", "a.b.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"img*.example.com", "img01.example.com", true},
+		{"img*.example.com", "static.example.com", false},
+		{"*.cdn.*.com", "a.cdn.b.com", true},
+		{"*.cdn.*.com", "a.cdn.com", false},
+		{"a*a", "a", false},
+		{"a*a", "aa", true},
+		{"http://www.example.com/path", "www.example.com", true},
+		{"https://*.example.com", "cdn.example.com", true},
+	}
+	for _, tt := range tests {
+		if got := matchReferer(tt.pattern, tt.host); got != tt.want {
+			t.Errorf("matchReferer(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.want)
+		}
+	}
+}
@@ -47,7 +47,24 @@ func (s *SCS) ListBuckets() ([]Bucket, error) {
 	return result, nil
 }
 
-//GetBucket 获取bucket实例
+//Bucket 返回名为name的bucket实例，不发送请求也不检查bucket是否存在
+func (s *SCS) Bucket(name string) *Bucket {
+	return &Bucket{Name: name, c: s.c}
+}
+
+//BucketExists 通过获取bucket meta判断bucket是否存在
+func (s *SCS) BucketExists(name string) (bool, error) {
+	_, err := s.GetBucketMeta(name)
+	if err == nil {
+		return true, nil
+	}
+	if isNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+//GetBucket 获取bucket实例，需要列出所有bucket，只需要实例时使用Bucket
 func (s *SCS) GetBucket(name string) (Bucket, error) {
 	var b Bucket
 	bl, err := s.ListBuckets()
@@ -130,13 +147,3 @@ func (s *SCS) DeleteBucket(name string) error {
 	}
 	return nil
 }
-
-//GetBucketACL 获取bucket acl
-func (s *SCS) GetBucketACL(name string) error {
-	return errors.New("no implement")
-}
-
-//PutBucketACL 指定Bucket设置ACL规则
-func (s *SCS) PutBucketACL(name string) error {
-	return errors.New("no implement")
-}
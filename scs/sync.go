@@ -0,0 +1,348 @@
+package scs
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sync动作类型
+const (
+	SyncUpload   = "upload"
+	SyncRelax    = "relax"
+	SyncUpdate   = "update"
+	SyncDownload = "download"
+	SyncCopy     = "copy"
+	SyncDelete   = "delete"
+	SyncMove     = "move"
+	SyncAbort    = "abort"
+	SyncSkip     = "skip"
+)
+
+// SyncOptions Sync可选参数
+type SyncOptions struct {
+	// Delete 删除源端不存在的目标文件，有上传失败时不删除
+	Delete bool
+	// Include 只同步匹配的相对路径，为空时全部同步，规则同path.Match，不含"/"的规则也匹配文件名
+	Include []string
+	// Exclude 跳过匹配的相对路径，优先于Include
+	Exclude []string
+	// DryRun 只生成报告，不执行任何修改
+	DryRun bool
+	// Concurrency 并发数，默认4
+	Concurrency int
+	// Progress 每个文件的传输进度，事件的Key为object key
+	Progress ProgressListener
+	// ReadLimiter WriteLimiter 下载和上传共用的限速器，为nil时使用全局限速
+	ReadLimiter  *RateLimiter
+	WriteLimiter *RateLimiter
+	// Headers 返回上传key时附加的请求头，如Cache-Control，为nil时只设置Content-Type。
+	// 内容没有变化但请求头不同时，通过服务端复制替换object的请求头，保留原有的x-amz-meta-*
+	Headers func(key string) map[string]string
+}
+
+// SyncAction 一次同步动作
+type SyncAction struct {
+	Op   string
+	Key  string
+	Path string
+	Size int64
+	Err  error
+}
+
+// SyncReport 同步结果汇总
+type SyncReport struct {
+	Actions    []SyncAction
+	Uploaded   int
+	Relaxed    int
+	Updated    int
+	Downloaded int
+	Deleted    int
+	Skipped    int
+	Failed     int
+	// Bytes 实际传输的字节数
+	Bytes int64
+}
+
+func (r *SyncReport) add(a SyncAction) {
+	r.Actions = append(r.Actions, a)
+	if a.Err != nil {
+		r.Failed++
+		return
+	}
+	switch a.Op {
+	case SyncUpload:
+		r.Uploaded++
+		r.Bytes += a.Size
+	case SyncRelax:
+		r.Relaxed++
+	case SyncUpdate:
+		r.Updated++
+	case SyncDownload:
+		r.Downloaded++
+		r.Bytes += a.Size
+	case SyncDelete:
+		r.Deleted++
+	case SyncSkip:
+		r.Skipped++
+	}
+}
+
+// Sync 将本地目录同步到bucket的prefix下，按大小和MD5/SHA1比较只上传有变化的文件，
+// 服务端已存在相同SHA1的内容时使用秒传。Delete为true时在全部上传成功后批量删除多余的object
+func Sync(ctx context.Context, localDir string, b *Bucket, prefix string, opts *SyncOptions) (*SyncReport, error) {
+	var o SyncOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	ctx = withLimiters(ctx, o.ReadLimiter, o.WriteLimiter)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	t, err := listSyncTarget(ctx, b, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var report SyncReport
+	local := make(map[string]bool)
+	err = t.upload(ctx, localDir, &o, &report, local)
+	if err == nil && o.Delete && report.Failed == 0 {
+		err = t.deleteStale(ctx, local, &o, &report)
+	}
+	sort.Slice(report.Actions, func(i, j int) bool {
+		return report.Actions[i].Key < report.Actions[j].Key
+	})
+	if err != nil {
+		return &report, err
+	}
+	if report.Failed > 0 {
+		return &report, fmt.Errorf("%d sync actions failed", report.Failed)
+	}
+	return &report, nil
+}
+
+// syncTarget Sync的目标，bucket中prefix下已有的object
+type syncTarget struct {
+	b      *Bucket
+	prefix string
+	remote map[string]Object
+	// stored 已有object内容的SHA1，用于秒传
+	stored map[string]bool
+}
+
+// listSyncTarget 列出bucket中prefix下的object，prefix为空或以"/"结尾
+func listSyncTarget(ctx context.Context, b *Bucket, prefix string) (*syncTarget, error) {
+	t := &syncTarget{b: b, prefix: prefix, remote: make(map[string]Object), stored: make(map[string]bool)}
+	err := b.walk(ctx, prefix, func(obj Object) error {
+		t.remote[obj.Name] = obj
+		if obj.SHA1 != "" {
+			t.stored[strings.ToLower(obj.SHA1)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// upload 并发上传localDir中满足o.Include和o.Exclude的文件，动作加入report，对应的key加入local
+func (t *syncTarget) upload(ctx context.Context, localDir string, o *SyncOptions, report *SyncReport, local map[string]bool) error {
+	var mu sync.Mutex
+	pool := newWorkerPool(ctx, o.Concurrency)
+	err := filepath.Walk(localDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !matchFilters(rel, o.Include, o.Exclude) {
+			return nil
+		}
+		key := t.prefix + rel
+		local[key] = true
+		obj, exists := t.remote[key]
+		size := info.Size()
+		return pool.submit(func() {
+			a := syncFile(ctx, t.b, p, key, size, obj, exists, t.stored, o)
+			mu.Lock()
+			report.add(a)
+			mu.Unlock()
+		})
+	})
+	pool.wait()
+	return err
+}
+
+// deleteStale 批量删除不在local中且满足o.Include和o.Exclude的object，动作加入report
+func (t *syncTarget) deleteStale(ctx context.Context, local map[string]bool, o *SyncOptions, report *SyncReport) error {
+	var stale []string
+	for key := range t.remote {
+		if local[key] || strings.HasSuffix(key, "/") || !matchFilters(strings.TrimPrefix(key, t.prefix), o.Include, o.Exclude) {
+			continue
+		}
+		stale = append(stale, key)
+	}
+	sort.Strings(stale)
+	var results []DeleteResult
+	if !o.DryRun && len(stale) > 0 {
+		results, _ = t.b.deleteMany(ctx, stale)
+	}
+	for i, key := range stale {
+		a := SyncAction{Op: SyncDelete, Key: key, Size: t.remote[key].Size}
+		if results != nil {
+			a.Err = results[i].Err
+		}
+		report.add(a)
+	}
+	return ctx.Err()
+}
+
+// syncFile 比较并上传单个文件
+func syncFile(ctx context.Context, b *Bucket, p, key string, size int64, obj Object, exists bool, stored map[string]bool, o *SyncOptions) SyncAction {
+	a := SyncAction{Op: SyncUpload, Key: key, Path: p, Size: size}
+	md5sum, sha1sum, err := fileHashes(p)
+	if err != nil {
+		a.Err = err
+		return a
+	}
+	meta := make(map[string]string)
+	if ctype := mime.TypeByExtension(path.Ext(key)); ctype != "" {
+		meta["Content-Type"] = ctype
+	}
+	if o.Headers != nil {
+		for k, v := range o.Headers(key) {
+			meta[k] = v
+		}
+	}
+	if exists && obj.Size == size {
+		if (obj.MD5 != "" && strings.EqualFold(obj.MD5, md5sum)) || (obj.MD5 == "" && strings.EqualFold(obj.SHA1, sha1sum)) {
+			a.Op = SyncSkip
+			if o.Headers != nil {
+				a.Op, a.Err = updateHeaders(ctx, b, key, meta, o.DryRun)
+			}
+			return a
+		}
+	}
+	if stored[sha1sum] {
+		a.Op = SyncRelax
+		if o.DryRun {
+			return a
+		}
+		if err := b.putRelax(ctx, key, meta, sha1sum, size); err == nil {
+			return a
+		}
+		a.Op = SyncUpload
+	}
+	if o.DryRun {
+		return a
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		a.Err = err
+		return a
+	}
+	defer f.Close()
+	tracker := newProgressTracker(o.Progress, key, size)
+	tracker.started()
+	a.Err = tracker.finish(b.putObject(withProgress(ctx, tracker), key, meta, f, size))
+	return a
+}
+
+// updateHeaders 比较object的请求头与meta，有不同时通过服务端复制替换，返回SyncUpdate，相同时返回SyncSkip
+func updateHeaders(ctx context.Context, b *Bucket, key string, meta map[string]string, dryRun bool) (string, error) {
+	header, err := b.headHeader(ctx, key)
+	if err != nil {
+		return SyncUpdate, err
+	}
+	changed := false
+	for k, v := range meta {
+		if header.Get(k) != v {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return SyncSkip, nil
+	}
+	if dryRun {
+		return SyncUpdate, nil
+	}
+	replace := make(map[string]string)
+	for k, v := range header {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-meta-") && len(v) > 0 {
+			replace[k] = v[0]
+		}
+	}
+	if ctype := header.Get("Content-Type"); ctype != "" {
+		replace["Content-Type"] = ctype
+	}
+	for k, v := range meta {
+		replace[http.CanonicalHeaderKey(k)] = v
+	}
+	return SyncUpdate, b.copyObject(ctx, key, b.Name, key, replace)
+}
+
+// fileHashes 计算文件的MD5和SHA1，返回小写十六进制
+func fileHashes(p string) (string, string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+	m := md5.New()
+	s := sha1.New()
+	if _, err := io.Copy(io.MultiWriter(m, s), f); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(m.Sum(nil)), hex.EncodeToString(s.Sum(nil)), nil
+}
+
+// matchFilters 判断相对路径是否满足include和exclude规则
+func matchFilters(rel string, include, exclude []string) bool {
+	for _, pattern := range exclude {
+		if matchPattern(pattern, rel) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pattern := range include {
+		if matchPattern(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(pattern, rel string) bool {
+	if ok, _ := path.Match(pattern, rel); ok {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return false
+}
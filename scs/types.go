@@ -1,6 +1,10 @@
 package scs
 
-import "github.com/Arvintian/scs-go-sdk/pkg/client"
+import (
+	"time"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
 
 //SCS type
 type SCS struct {
@@ -28,6 +32,12 @@ type BucketMeta struct {
 	Quantity         int64  `json:"Quantity"`
 }
 
+// LastModifiedTime 解析后的LastModified，无法解析时为零值
+func (m BucketMeta) LastModifiedTime() time.Time {
+	t, _ := parseTime(m.LastModified)
+	return t
+}
+
 //ACL acl规则
 type ACL map[string][]string
 
@@ -51,6 +61,12 @@ type Bucket struct {
 	c             *client.Client
 }
 
+// CreationTime 解析后的CreationDate，无法解析时为零值
+func (b Bucket) CreationTime() time.Time {
+	t, _ := parseTime(b.CreationDate)
+	return t
+}
+
 // Owner type
 type Owner struct {
 	ID          string `json:"ID"`
@@ -59,11 +75,23 @@ type Owner struct {
 
 // ObjectMeta type
 type ObjectMeta struct {
-	ContentType   string
-	ContentLength int64
-	ETag          string
-	LastModified  string
-	XAmzMeta      map[string]string
+	ContentType string
+	// ContentLength object大小，服务端没有返回时为-1，Open和FS会另外请求得到实际大小
+	ContentLength      int64
+	ETag               string
+	LastModified       string
+	CacheControl       string
+	ContentEncoding    string
+	ContentDisposition string
+	XAmzMeta           map[string]string
+	// Metadata 去掉x-amz-meta-前缀并转为小写的自定义meta
+	Metadata map[string]string
+}
+
+// LastModifiedTime 解析后的LastModified，无法解析时为零值
+func (m ObjectMeta) LastModifiedTime() time.Time {
+	t, _ := parseTime(m.LastModified)
+	return t
 }
 
 // ListObject type
@@ -95,6 +123,12 @@ type Object struct {
 	Size         int64  `json:"Size"`
 }
 
+// LastModifiedTime 解析后的LastModified，无法解析时为零值
+func (o Object) LastModifiedTime() time.Time {
+	t, _ := parseTime(o.LastModified)
+	return t
+}
+
 // MultipartUpload type
 type MultipartUpload struct {
 	Bucket   string `json:"Bucket"`
@@ -102,6 +136,31 @@ type MultipartUpload struct {
 	UploadID string `json:"UploadId"`
 }
 
+// ListMultipartUpload type
+type ListMultipartUpload struct {
+	Bucket             string   `json:"Bucket"`
+	Prefix             string   `json:"Prefix"`
+	KeyMarker          string   `json:"KeyMarker"`
+	UploadIDMarker     string   `json:"UploadIdMarker"`
+	NextKeyMarker      string   `json:"NextKeyMarker"`
+	NextUploadIDMarker string   `json:"NextUploadIdMarker"`
+	IsTruncated        bool     `json:"IsTruncated"`
+	Uploads            []Upload `json:"Uploads"`
+}
+
+// Upload type
+type Upload struct {
+	Key       string `json:"Key"`
+	UploadID  string `json:"UploadId"`
+	Initiated string `json:"Initiated"`
+}
+
+// InitiatedTime 解析后的Initiated，无法解析时为零值
+func (u Upload) InitiatedTime() time.Time {
+	t, _ := parseTime(u.Initiated)
+	return t
+}
+
 // Part type
 type Part struct {
 	PartNumber   int    `json:"PartNumber"`
@@ -110,6 +169,12 @@ type Part struct {
 	Size         int    `json:"Size"`
 }
 
+// LastModifiedTime 解析后的LastModified，无法解析时为零值
+func (p Part) LastModifiedTime() time.Time {
+	t, _ := parseTime(p.LastModified)
+	return t
+}
+
 // ListPart type
 type ListPart struct {
 	Bucket string `json:"Bucket"`
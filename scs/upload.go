@@ -0,0 +1,59 @@
+package scs
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// putStream 上传长度未知的reader，不超过MD5Threshold时在内存中缓冲后直接上传，
+// 否则按PartSize切分，逐片在内存中计算MD5后走分片上传
+func (b *Bucket) putStream(ctx context.Context, key string, XAmzMeta map[string]string, data io.Reader) error {
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, data, MD5Threshold+1)
+	if err == io.EOF {
+		return b.putObject(ctx, key, XAmzMeta, &buf, n)
+	}
+	if err != nil {
+		return err
+	}
+	mu, err := b.initiateMultipartUpload(ctx, key, XAmzMeta)
+	if err != nil {
+		return err
+	}
+	parts, err := b.uploadParts(ctx, key, mu.UploadID, io.MultiReader(&buf, data))
+	if err != nil {
+		b.AbortMultipartUpload(key, mu.UploadID)
+		return err
+	}
+	if err := b.completeMultipartUpload(ctx, key, mu.UploadID, parts); err != nil {
+		b.AbortMultipartUpload(key, mu.UploadID)
+		return err
+	}
+	return nil
+}
+
+// uploadParts 将data按PartSize切分后依次上传
+func (b *Bucket) uploadParts(ctx context.Context, key, uploadID string, data io.Reader) ([]Part, error) {
+	parts := make([]Part, 0)
+	part := bytes.NewBuffer(make([]byte, 0, PartSize))
+	for partNumber := 1; ; partNumber++ {
+		part.Reset()
+		n, err := io.CopyN(part, data, PartSize)
+		if err != nil && err != io.EOF {
+			return parts, err
+		}
+		if n == 0 {
+			break
+		}
+		p, err := b.uploadPart(ctx, key, uploadID, partNumber, bytes.NewReader(part.Bytes()))
+		if err != nil {
+			return parts, err
+		}
+		parts = append(parts, p)
+		if n < PartSize {
+			break
+		}
+	}
+	return parts, nil
+}
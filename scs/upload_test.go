@@ -0,0 +1,74 @@
+package scs
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// putServer 记录PUT请求body的测试服务端
+type putServer struct {
+	mu   sync.Mutex
+	objs map[string][]byte
+}
+
+func (s *putServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil || r.ContentLength != int64(len(body)) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.objs[r.URL.Path] = body
+	s.mu.Unlock()
+}
+
+func testBucket(t *testing.T, h http.Handler) *Bucket {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	s, err := NewSCS("ak", "sk", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Bucket("b")
+}
+
+func TestPutLimitedReader(t *testing.T) {
+	srv := &putServer{objs: make(map[string][]byte)}
+	b := testBucket(t, srv)
+	tests := []struct {
+		name string
+		data io.Reader
+		want string
+	}{
+		{"limit larger than data", io.LimitReader(strings.NewReader("hello"), 100), "hello"},
+		{"unbounded limit", io.LimitReader(strings.NewReader("hello"), math.MaxInt64), "hello"},
+		{"limit shorter than data", io.LimitReader(strings.NewReader("hello world"), 5), "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if isBuffered(tt.data) {
+				t.Fatal("LimitedReader treated as known length")
+			}
+			if err := b.Put("k", nil, tt.data); err != nil {
+				t.Fatal(err)
+			}
+			srv.mu.Lock()
+			got := string(srv.objs["/b/k"])
+			srv.mu.Unlock()
+			if got != tt.want {
+				t.Fatalf("uploaded %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
-	"hash/crc32"
 	"io"
-	"io/ioutil"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
 )
 
 // GetReaderLen 获取reader length
@@ -38,24 +41,43 @@ func GetReaderLen(reader io.Reader) (int64, error) {
 	return contentLength, err
 }
 
-func calcMD5(body io.Reader, contentLen int64) (reader io.Reader, b64 string, tempFile *os.File, err error) {
-	if contentLen == 0 || contentLen > MD5Threshold {
-		// Huge body, use temporary file
-		tempFile, err = ioutil.TempFile(os.TempDir(), TempFilePrefix)
-		if tempFile != nil {
-			io.Copy(tempFile, body)
-			tempFile.Seek(0, os.SEEK_SET)
-			md5 := md5.New()
-			io.Copy(md5, tempFile)
-			sum := md5.Sum(nil)
-			b64 = base64.StdEncoding.EncodeToString(sum[:])
-			tempFile.Seek(0, os.SEEK_SET)
-			reader = tempFile
+// isBuffered 判断reader的长度已知且可以直接计算MD5，不需要额外缓冲。
+// 只有普通文件按已知长度处理，标准输入、管道等其他文件长度未知且不能Seek。
+// io.LimitedReader的N只是上限，实际数据可能更短，按长度未知处理
+func isBuffered(reader io.Reader) bool {
+	switch v := reader.(type) {
+	case *bytes.Buffer, *bytes.Reader, *strings.Reader:
+		return true
+	case *os.File:
+		info, err := v.Stat()
+		return err == nil && info.Mode().IsRegular()
+	}
+	return false
+}
+
+func calcMD5(body io.Reader, contentLen int64) (reader io.Reader, b64 string, err error) {
+	if rs, ok := body.(io.ReadSeeker); ok {
+		// Seekable body, hash then rewind
+		var pos int64
+		pos, err = rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return
+		}
+		md5 := md5.New()
+		if _, err = io.CopyN(md5, rs, contentLen); err != nil {
+			return
+		}
+		if _, err = rs.Seek(pos, io.SeekStart); err != nil {
+			return
 		}
+		b64 = base64.StdEncoding.EncodeToString(md5.Sum(nil))
+		reader = io.LimitReader(rs, contentLen)
 	} else {
-		// Small body, use memory
-		var buf []byte
-		buf, err = ioutil.ReadAll(body)
+		// Otherwise use memory
+		buf := make([]byte, contentLen)
+		if _, err = io.ReadFull(body, buf); err != nil {
+			return
+		}
 		sum := md5.Sum(buf)
 		b64 = base64.StdEncoding.EncodeToString(sum[:])
 		reader = bytes.NewReader(buf)
@@ -63,27 +85,46 @@ func calcMD5(body io.Reader, contentLen int64) (reader io.Reader, b64 string, te
 	return
 }
 
-func calcCRC32(body io.Reader, contentLen int64) (reader io.Reader, crc string, tempFile *os.File, err error) {
-	if contentLen == 0 || contentLen > MD5Threshold {
-		// Huge body, use temporary file
-		tempFile, err = ioutil.TempFile(os.TempDir(), TempFilePrefix)
-		if tempFile != nil {
-			io.Copy(tempFile, body)
-			tempFile.Seek(0, os.SEEK_SET)
-			mycrc32 := crc32.NewIEEE()
-			io.Copy(mycrc32, tempFile)
-			crc = strings.ToUpper(fmt.Sprintf("%x", mycrc32.Sum32()))
-			tempFile.Seek(0, os.SEEK_SET)
-			reader = tempFile
+// isNotFound 判断是否为404错误
+func isNotFound(err error) bool {
+	if err == ErrObjectNotFound {
+		return true
+	}
+	e, ok := err.(*client.Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// isErrorCode 判断是否为错误码为code的scs错误
+func isErrorCode(err error, code string) bool {
+	e, ok := err.(*client.Error)
+	return ok && e.ErrorCode == code
+}
+
+// timeLayouts scs在各接口中返回的时间格式，秒之后的小数部分都可以省略
+var timeLayouts = []string{
+	http.TimeFormat,
+	time.RFC1123,
+	time.RFC1123Z,
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	time.RFC850,
+	time.ANSIC,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05 -0700",
+}
+
+// parseTime 解析scs返回的时间字符串，也支持unix时间戳，没有时区的时间按UTC解析
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
 		}
-	} else {
-		// Small body, use memory
-		var buf []byte
-		buf, err = ioutil.ReadAll(body)
-		mycrc32 := crc32.NewIEEE()
-		mycrc32.Write(buf)
-		crc = strings.ToUpper(fmt.Sprintf("%x", mycrc32.Sum32()))
-		reader = bytes.NewReader(buf)
 	}
-	return
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("can't parse time %q", s)
 }
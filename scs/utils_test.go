@@ -0,0 +1,64 @@
+package scs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2026, 3, 7, 8, 9, 10, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"Sat, 07 Mar 2026 08:09:10 GMT", want},
+		{"Sat, 07 Mar 2026 08:09:10 UTC", want},
+		{"Sat, 7 Mar 2026 08:09:10 GMT", want},
+		{"Sat, 7 Mar 2026 08:09:10 +0000", want},
+		{"Sat, 07 Mar 2026 16:09:10 +0800", want},
+		{"Saturday, 07-Mar-26 08:09:10 UTC", want},
+		{"Sat Mar  7 08:09:10 2026", want},
+		{"2026-03-07T08:09:10Z", want},
+		{"2026-03-07T16:09:10+08:00", want},
+		{"2026-03-07T08:09:10.250Z", want.Add(250 * time.Millisecond)},
+		{"2026-03-07T08:09:10.123456+00:00", want.Add(123456 * time.Microsecond)},
+		{"2026-03-07T08:09:10", want},
+		{"2026-03-07T08:09:10.5", want.Add(500 * time.Millisecond)},
+		{"2026-03-07 08:09:10", want},
+		{"2026-03-07 16:09:10 +0800", want},
+		{" 2026-03-07 08:09:10 ", want},
+		{"1772870950", want},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.in)
+		if err != nil {
+			t.Errorf("parseTime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "yesterday", "2026-13-01T00:00:00Z", "Sat, 07 Mar 2026"} {
+		if _, err := parseTime(in); err == nil {
+			t.Errorf("parseTime(%q): expected error", in)
+		}
+	}
+}
+
+func TestLastModifiedTime(t *testing.T) {
+	const lm = "Sat, 07 Mar 2026 08:09:10 GMT"
+	want := time.Date(2026, 3, 7, 8, 9, 10, 0, time.UTC)
+	for name, got := range map[string]time.Time{
+		"Object":     Object{LastModified: lm}.LastModifiedTime(),
+		"ObjectMeta": ObjectMeta{LastModified: lm}.LastModifiedTime(),
+		"Part":       Part{LastModified: lm}.LastModifiedTime(),
+	} {
+		if !got.Equal(want) {
+			t.Errorf("%s.LastModifiedTime() = %v, want %v", name, got, want)
+		}
+	}
+	if !(Object{LastModified: "-"}).LastModifiedTime().IsZero() {
+		t.Error("unparsable LastModified should give zero time")
+	}
+}
@@ -0,0 +1,289 @@
+package scs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Arvintian/scs-go-sdk/pkg/client"
+)
+
+// WebsiteConfig bucket静态网站配置
+type WebsiteConfig struct {
+	// IndexDocument 访问目录时返回的文件名，如index.html
+	IndexDocument string
+	// ErrorDocument 出现4xx错误时返回的object key
+	ErrorDocument string
+	// RoutingRules 重定向规则，按顺序匹配
+	RoutingRules []RoutingRule
+}
+
+// RoutingRule 静态网站重定向规则，条件字段为空表示不限制
+type RoutingRule struct {
+	// KeyPrefixEquals 条件：key前缀
+	KeyPrefixEquals string
+	// HTTPErrorCodeReturnedEquals 条件：返回的错误码，0表示不限制
+	HTTPErrorCodeReturnedEquals int
+	// Protocol 重定向使用的协议，http或https
+	Protocol string
+	// HostName 重定向的域名
+	HostName string
+	// ReplaceKeyPrefixWith 替换匹配的key前缀，与ReplaceKeyWith二选一
+	ReplaceKeyPrefixWith string
+	// ReplaceKeyWith 替换整个key
+	ReplaceKeyWith string
+	// HTTPRedirectCode 重定向状态码，0表示默认的301
+	HTTPRedirectCode int
+}
+
+// websiteConfiguration 静态网站配置的xml格式
+type websiteConfiguration struct {
+	XMLName       xml.Name             `xml:"WebsiteConfiguration"`
+	IndexDocument *websiteIndex        `xml:"IndexDocument,omitempty"`
+	ErrorDocument *websiteError        `xml:"ErrorDocument,omitempty"`
+	RoutingRules  []websiteRoutingRule `xml:"RoutingRules>RoutingRule,omitempty"`
+}
+
+type websiteIndex struct {
+	Suffix string `xml:"Suffix"`
+}
+
+type websiteError struct {
+	Key string `xml:"Key"`
+}
+
+type websiteRoutingRule struct {
+	Condition *websiteCondition `xml:"Condition,omitempty"`
+	Redirect  websiteRedirect   `xml:"Redirect"`
+}
+
+type websiteCondition struct {
+	KeyPrefixEquals             string `xml:"KeyPrefixEquals,omitempty"`
+	HTTPErrorCodeReturnedEquals string `xml:"HttpErrorCodeReturnedEquals,omitempty"`
+}
+
+type websiteRedirect struct {
+	Protocol             string `xml:"Protocol,omitempty"`
+	HostName             string `xml:"HostName,omitempty"`
+	ReplaceKeyPrefixWith string `xml:"ReplaceKeyPrefixWith,omitempty"`
+	ReplaceKeyWith       string `xml:"ReplaceKeyWith,omitempty"`
+	HTTPRedirectCode     string `xml:"HttpRedirectCode,omitempty"`
+}
+
+// GetBucketWebsite 获取bucket静态网站配置，没有设置时返回nil，bucket不存在等其他错误照常返回
+func (s *SCS) GetBucketWebsite(name string) (*WebsiteConfig, error) {
+	var params = make(map[string][]string)
+	params["website"] = []string{""}
+	req := &client.Request{
+		Method: "GET",
+		Bucket: name,
+		Path:   "/",
+		Params: params,
+	}
+	_, rc, err := s.c.Query(req)
+	defer rc.Close()
+	if err != nil {
+		if isErrorCode(err, "NoSuchWebsiteConfiguration") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	bts, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	var conf websiteConfiguration
+	if err := xml.Unmarshal(bts, &conf); err != nil {
+		return nil, err
+	}
+	var wc WebsiteConfig
+	if conf.IndexDocument != nil {
+		wc.IndexDocument = conf.IndexDocument.Suffix
+	}
+	if conf.ErrorDocument != nil {
+		wc.ErrorDocument = conf.ErrorDocument.Key
+	}
+	for _, r := range conf.RoutingRules {
+		rule := RoutingRule{
+			Protocol:             r.Redirect.Protocol,
+			HostName:             r.Redirect.HostName,
+			ReplaceKeyPrefixWith: r.Redirect.ReplaceKeyPrefixWith,
+			ReplaceKeyWith:       r.Redirect.ReplaceKeyWith,
+		}
+		rule.HTTPRedirectCode, _ = strconv.Atoi(r.Redirect.HTTPRedirectCode)
+		if r.Condition != nil {
+			rule.KeyPrefixEquals = r.Condition.KeyPrefixEquals
+			rule.HTTPErrorCodeReturnedEquals, _ = strconv.Atoi(r.Condition.HTTPErrorCodeReturnedEquals)
+		}
+		wc.RoutingRules = append(wc.RoutingRules, rule)
+	}
+	return &wc, nil
+}
+
+// PutBucketWebsite 设置bucket静态网站配置
+func (s *SCS) PutBucketWebsite(name string, wc WebsiteConfig) error {
+	var conf websiteConfiguration
+	if wc.IndexDocument != "" {
+		conf.IndexDocument = &websiteIndex{Suffix: wc.IndexDocument}
+	}
+	if wc.ErrorDocument != "" {
+		conf.ErrorDocument = &websiteError{Key: wc.ErrorDocument}
+	}
+	for _, rule := range wc.RoutingRules {
+		var r websiteRoutingRule
+		if rule.KeyPrefixEquals != "" || rule.HTTPErrorCodeReturnedEquals != 0 {
+			r.Condition = &websiteCondition{KeyPrefixEquals: rule.KeyPrefixEquals}
+			if rule.HTTPErrorCodeReturnedEquals != 0 {
+				r.Condition.HTTPErrorCodeReturnedEquals = strconv.Itoa(rule.HTTPErrorCodeReturnedEquals)
+			}
+		}
+		r.Redirect = websiteRedirect{
+			Protocol:             rule.Protocol,
+			HostName:             rule.HostName,
+			ReplaceKeyPrefixWith: rule.ReplaceKeyPrefixWith,
+			ReplaceKeyWith:       rule.ReplaceKeyWith,
+		}
+		if rule.HTTPRedirectCode != 0 {
+			r.Redirect.HTTPRedirectCode = strconv.Itoa(rule.HTTPRedirectCode)
+		}
+		conf.RoutingRules = append(conf.RoutingRules, r)
+	}
+	data, err := xml.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(data)
+	var params = make(map[string][]string)
+	params["website"] = []string{""}
+	var headers = make(http.Header)
+	headers.Set("Content-Type", "application/xml")
+	headers.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	headers.Set("Content-Length", strconv.Itoa(len(data)))
+	req := &client.Request{
+		Method:  "PUT",
+		Bucket:  name,
+		Path:    "/",
+		Params:  params,
+		Headers: headers,
+		Body:    bytes.NewReader(data),
+	}
+	_, body, err := s.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteBucketWebsite 删除bucket静态网站配置
+func (s *SCS) DeleteBucketWebsite(name string) error {
+	var params = make(map[string][]string)
+	params["website"] = []string{""}
+	req := &client.Request{
+		Method: "DELETE",
+		Bucket: name,
+		Path:   "/",
+		Params: params,
+	}
+	_, body, err := s.c.Query(req)
+	defer body.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeployOptions DeploySite可选参数
+type DeployOptions struct {
+	// Prefix 网站在bucket中的key前缀
+	Prefix string
+	// Concurrency 并发数，默认4
+	Concurrency int
+	// DryRun 只生成报告，不执行任何修改
+	DryRun bool
+	// KeepStale 不删除本地已不存在的远端文件
+	KeepStale bool
+	// CacheControl 按扩展名(如".css")设置的Cache-Control，覆盖默认值。
+	// 内容没有变化但Cache-Control不同的文件通过服务端复制更新，报告中记为SyncUpdate
+	CacheControl map[string]string
+	// Progress 每个文件的上传进度
+	Progress ProgressListener
+}
+
+// defaultCacheControl 默认的Cache-Control，html等入口文件每次校验，其他静态资源缓存一天
+func defaultCacheControl(ext string) string {
+	switch ext {
+	case ".html", ".htm", ".xml", ".json", ".txt", ".webmanifest":
+		return "no-cache"
+	}
+	return "public, max-age=86400"
+}
+
+// htmlPatterns 匹配html文件的规则，扩展名不区分大小写
+var htmlPatterns = []string{"*.[hH][tT][mM]", "*.[hH][tT][mM][lL]"}
+
+// DeploySite 将本地构建好的网站目录dir部署到bucket，按扩展名设置Content-Type和Cache-Control。
+// 先上传html以外的静态资源，全部成功后再上传html文件，最后删除远端多余的文件，避免访问者看到部署了一半的网站。
+// 远端只列出一次，每个文件只计算一次哈希
+func DeploySite(ctx context.Context, dir string, b *Bucket, opts *DeployOptions) (*SyncReport, error) {
+	var o DeployOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	prefix := o.Prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	so := SyncOptions{
+		Concurrency: o.Concurrency,
+		DryRun:      o.DryRun,
+		Progress:    o.Progress,
+		Headers: func(key string) map[string]string {
+			ext := strings.ToLower(path.Ext(key))
+			cc, ok := o.CacheControl[ext]
+			if !ok {
+				cc = defaultCacheControl(ext)
+			}
+			return map[string]string{"Cache-Control": cc}
+		},
+	}
+	t, err := listSyncTarget(ctx, b, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var report SyncReport
+	local := make(map[string]bool)
+	assets := so
+	assets.Exclude = htmlPatterns
+	err = t.upload(ctx, dir, &assets, &report, local)
+	if err == nil && report.Failed == 0 {
+		pages := so
+		pages.Include = htmlPatterns
+		err = t.upload(ctx, dir, &pages, &report, local)
+	}
+	if err == nil && report.Failed == 0 && !o.KeepStale {
+		err = t.deleteStale(ctx, local, &so, &report)
+	}
+	sort.Slice(report.Actions, func(i, j int) bool {
+		return report.Actions[i].Key < report.Actions[j].Key
+	})
+	if err != nil {
+		return &report, err
+	}
+	if report.Failed > 0 {
+		return &report, fmt.Errorf("%d deploy actions failed", report.Failed)
+	}
+	return &report, nil
+}
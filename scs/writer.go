@@ -0,0 +1,247 @@
+package scs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+var errWriterClosed = errors.New("writer closed")
+
+// WriterOptions NewWriter可选参数
+type WriterOptions struct {
+	// XAmzMeta 上传时附带的x-amz-meta-*等请求头
+	XAmzMeta map[string]string
+	// PartSize 每个分片的大小，默认PartSize
+	PartSize int64
+	// Concurrency 后台同时上传的分片数，默认4
+	Concurrency int
+	// Compression 写入时使用的压缩算法，同WithCompression
+	Compression string
+	// Progress 上传进度，同WithProgress
+	Progress ProgressListener
+	// RateLimiter 上传限速，为nil时使用全局限速，同WithRateLimiter
+	RateLimiter *RateLimiter
+}
+
+// Writer object写入器，数据在内存中按分片缓冲并在后台上传，Close时完成上传。
+// 数据不超过一个分片时直接Put，不走分片上传。Writer不能被多个goroutine同时使用。
+type Writer struct {
+	b          *Bucket
+	key        string
+	opts       WriterOptions
+	ctx        context.Context
+	cancel     context.CancelFunc
+	buf        *bytes.Buffer
+	zw         io.WriteCloser
+	tracker    *progressTracker
+	uploadID   string
+	partNumber int
+	sem        chan struct{}
+	wg         sync.WaitGroup
+	mu         sync.Mutex
+	parts      []Part
+	err        error
+	closed     bool
+}
+
+var _ io.WriteCloser = (*Writer)(nil)
+
+// NewWriter 创建object写入器，ctx被取消后写入失败，Close时取消已开始的分片上传
+func (b *Bucket) NewWriter(ctx context.Context, key string, opts *WriterOptions) *Writer {
+	var o WriterOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.PartSize <= 0 {
+		o.PartSize = PartSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	tracker := newProgressTracker(o.Progress, key, -1)
+	tracker.started()
+	ctx, cancel := context.WithCancel(withProgress(withLimiters(ctx, nil, o.RateLimiter), tracker))
+	w := &Writer{
+		b:       b,
+		key:     key,
+		opts:    o,
+		ctx:     ctx,
+		cancel:  cancel,
+		buf:     bytes.NewBuffer(make([]byte, 0, o.PartSize)),
+		tracker: tracker,
+		sem:     make(chan struct{}, o.Concurrency),
+	}
+	if o.Compression != "" {
+		c, err := getCodec(o.Compression)
+		if err == nil {
+			w.opts.XAmzMeta = compressMeta(c, o.XAmzMeta, -1)
+			w.zw, err = c.NewWriter(rawWriter{w})
+		}
+		if err != nil {
+			w.setError(err)
+		}
+	}
+	return w
+}
+
+// rawWriter 压缩后的数据写入Writer的缓冲
+type rawWriter struct {
+	w *Writer
+}
+
+func (r rawWriter) Write(p []byte) (int, error) {
+	return r.w.write(p)
+}
+
+// Write 写入数据，缓冲满一个分片后在后台上传
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errWriterClosed
+	}
+	if err := w.error(); err != nil {
+		return 0, err
+	}
+	if w.zw != nil {
+		return w.zw.Write(p)
+	}
+	return w.write(p)
+}
+
+func (w *Writer) write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if int64(w.buf.Len()) >= w.opts.PartSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+		n := int(w.opts.PartSize) - w.buf.Len()
+		if n > len(p) {
+			n = len(p)
+		}
+		w.buf.Write(p[:n])
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close 上传剩余数据并完成上传，失败时取消分片上传
+func (w *Writer) Close() error {
+	if w.closed {
+		return errWriterClosed
+	}
+	w.closed = true
+	defer w.cancel()
+	return w.tracker.finish(w.close())
+}
+
+func (w *Writer) close() error {
+	if w.zw != nil && w.error() == nil {
+		if err := w.zw.Close(); err != nil {
+			w.setError(err)
+		}
+	}
+	if w.uploadID == "" {
+		if err := w.error(); err != nil {
+			return err
+		}
+		return w.b.putObject(w.ctx, w.key, w.opts.XAmzMeta, bytes.NewReader(w.buf.Bytes()), int64(w.buf.Len()))
+	}
+	if w.buf.Len() > 0 {
+		w.flush()
+	}
+	w.wg.Wait()
+	if err := w.error(); err != nil {
+		w.b.AbortMultipartUpload(w.key, w.uploadID)
+		return err
+	}
+	sort.Slice(w.parts, func(i, j int) bool {
+		return w.parts[i].PartNumber < w.parts[j].PartNumber
+	})
+	if err := w.b.completeMultipartUpload(w.ctx, w.key, w.uploadID, w.parts); err != nil {
+		w.b.AbortMultipartUpload(w.key, w.uploadID)
+		return err
+	}
+	return nil
+}
+
+// CloseWithError 放弃写入，取消正在进行的分片上传，之后的Write返回err
+func (w *Writer) CloseWithError(err error) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	w.closed = true
+	if err == nil {
+		err = errors.New("writer aborted")
+	}
+	w.setError(err)
+	w.cancel()
+	w.wg.Wait()
+	w.tracker.finish(err)
+	if w.uploadID != "" {
+		return w.b.AbortMultipartUpload(w.key, w.uploadID)
+	}
+	return nil
+}
+
+// flush 将当前缓冲作为一个分片交给后台上传
+func (w *Writer) flush() error {
+	if err := w.error(); err != nil {
+		return err
+	}
+	if w.uploadID == "" {
+		mu, err := w.b.initiateMultipartUpload(w.ctx, w.key, w.opts.XAmzMeta)
+		if err != nil {
+			w.setError(err)
+			return err
+		}
+		w.uploadID = mu.UploadID
+	}
+	select {
+	case w.sem <- struct{}{}:
+	case <-w.ctx.Done():
+		w.setError(w.ctx.Err())
+		return w.ctx.Err()
+	}
+	w.partNumber++
+	partNumber := w.partNumber
+	data := w.buf.Bytes()
+	w.buf = bytes.NewBuffer(make([]byte, 0, w.opts.PartSize))
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() { <-w.sem }()
+		p, err := w.b.uploadPart(w.ctx, w.key, w.uploadID, partNumber, bytes.NewReader(data))
+		if err != nil {
+			w.setError(err)
+			w.cancel()
+			return
+		}
+		w.mu.Lock()
+		w.parts = append(w.parts, p)
+		w.mu.Unlock()
+	}()
+	return nil
+}
+
+func (w *Writer) setError(err error) {
+	w.mu.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.mu.Unlock()
+}
+
+func (w *Writer) error() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	return w.ctx.Err()
+}